@@ -3,8 +3,10 @@ package main
 import (
 	"flag" // for flag-handling related work
 	"fmt"
+	"os"
 
 	ps "../../postscript"
+	"../../postscript/objects"
 	"../../xpm"
 )
 
@@ -16,23 +18,34 @@ USAGE: cmd.exe -f /path/to/input.ps -w 200 -h 200 -o /path/to/output.xpm
 	Mandatory argument.
 -w:
 	Width of the output XPM bitmap file.
-	Mandatory. Must be greater than 0.
+	Mandatory unless the input file has a %%BoundingBox, in which case the bitmap
+	covers just the box, with its lower left corner at the origin. Must be greater than 0.
 -h:
 	Height of the output XPM bitmap file.
-	Mandatory. Must be greater than 0.
+	Mandatory unless the input file has a %%BoundingBox. Must be greater than 0.
 -o:
 	Path to the output XPM bitmap file.
 	Default value is ./output.xpm
+	A multi-page input yields one output file per page, numbered before the
+	extension (i.e. ./output-1.xpm, ./output-2.xpm, ...).
+-page:
+	Number of the single page of the input to render, starting from 1.
+	Its bitmap is written to the output file as-is. By default, all pages are rendered.
+-aa:
+	Draw anti-aliased lines, blending them into the background with intermediate colors.
+	By default, lines are drawn with plain Bresenham pixels.
+-dashpx:
+	Measure the dash patterns set by setdash in pixels drawn rather than in length along the lines.
 `[1:]
 
 // height command line argument
 // usage: -h UINT
-// mandatory
+// mandatory unless the input has a bounding box
 var height int
 
 // width command line argument
 // usage: -w UINT
-// mandatory
+// mandatory unless the input has a bounding box
 var width int
 
 // postscript input file command line argument
@@ -40,17 +53,52 @@ var width int
 // mandatory
 var input string
 
+// bounding box of the input, when the bitmap is sized after it; shapes are
+// moved along with it onto the bitmap as they are drawn
+var origin *ps.Box
+
 // xpm output file command line argument
 // usage: -o /path/to/file.xpm
 // default: ./output.xpm
 var output string
 
+// page to render command line argument
+// usage: -page UINT
+// default: 0, for all the pages
+var page int
+
+// anti-aliasing command line argument
+// usage: -aa
+// default: false
+var antialias bool
+
+// pixel-measured dashes command line argument
+// usage: -dashpx
+// default: false
+var dashpx bool
+
+// draw has the given shape draw itself to the bitmap, anti-aliased if so
+// requested, and moved along with the bounding box the bitmap is sized after
+// if any
+func draw(shape objects.Shape, bitmap *xpm.XPM) error {
+	if origin != nil {
+		shape = shape.Transform(origin)
+	}
+	if antialias {
+		return objects.DrawAntialiased(shape, bitmap, "b")
+	}
+	return shape.Draw(bitmap, "b")
+}
+
 // flaginit sets up all command line flag handling
 func flaginit() {
 	flag.IntVar(&width, "w", 0, "width of the resulting bitmap")
 	flag.IntVar(&height, "h", 0, "height of the resulting bitmap")
 	flag.StringVar(&input, "f", "", "postscript input file given for processing")
 	flag.StringVar(&output, "o", "./output.xpm", "output file for resulting bitmap")
+	flag.IntVar(&page, "page", 0, "single page of the input to render")
+	flag.BoolVar(&antialias, "aa", false, "draw anti-aliased lines")
+	flag.BoolVar(&dashpx, "dashpx", false, "measure dash patterns in pixels")
 	flag.Parse()
 }
 
@@ -64,36 +112,101 @@ func main() {
 	// initialize all command line flags
 	flaginit()
 
+	// read the input's metadata; without explicit dimensions,
+	// the bitmap is sized after the input's bounding box
+	var dsc *ps.DSC
+	if input != "" {
+		dsc, err = ps.ReadDSCFile(input)
+		if err != nil {
+			fmt.Printf("Error reading input file %s:\n%s\n", input, err)
+			return
+		}
+	}
+	if dsc != nil && dsc.Bounds() != nil && (width <= 0 || height <= 0) {
+		origin = dsc.Bounds()
+		width, height = origin.Size()
+	}
+	if dsc != nil {
+		for _, w := range dsc.Warnings {
+			fmt.Printf("Warning: %s\n", w)
+		}
+	}
+
 	// check all arguments
-	if height <= 0 || width <= 0 || input == "" {
+	if height <= 0 || width <= 0 || input == "" || page < 0 {
 		fmt.Println(usage)
 		return
 	}
 
-	// create XPM struct to be worked on
-	xpm := xpm.NewXPM(width, height, 1)
-
-	// add our preffered color for all subsequent line designs
+	// newBitmap creates a blank XPM struct to be worked on for every page,
+	// with our preffered color for all subsequent line designs
 	// in this case, 100% blue balance
-	xpm.AddColor(0, 0, 255, "b")
+	newBitmap := func() *xpm.XPM {
+		bitmap := xpm.NewXPM(width, height, 1)
+		bitmap.AddColor(0, 0, 255, "b")
+		return bitmap
+	}
 
-	// parse the input file
-	lines, err := ps.ParseFile(input)
+	// writeBitmap writes out the bitmap of the given page, to a numbered
+	// file if the input is known to have several pages
+	writeBitmap := func(bitmap *xpm.XPM, n int, numbered bool) {
+		filename := output
+		if numbered {
+			filename = ps.PageFilename(output, n)
+		}
+		if err := bitmap.WriteToFile(filename); err != nil {
+			fmt.Printf("Error writing output file %s:\n%s", filename, err)
+		}
+	}
+
+	f, err := os.Open(input)
 	if err != nil {
-		fmt.Printf("Error parsing input file %s:\n%s\n", input, err)
+		fmt.Printf("Error opening input file %s:\n%s\n", input, err)
 		return
 	}
+	defer f.Close()
+
+	// parse the input file, having each stroked line and filled polygon
+	// draw itself to the bitmap of its page as soon as it is painted
+	// a page is written out as soon as the next one comes along, which is
+	// also how we first learn that the output files are to be numbered
+	d := ps.NewDecoder(f)
+	if dashpx {
+		d.SetDashMeasure(objects.DashByPixels)
+	}
+	current, bitmap := 1, newBitmap()
+	i := 0
+	err = d.WalkPages(func(n int, shape objects.Shape) error {
+		if page > 0 && n != page {
+			return nil
+		}
+		for page == 0 && current < n {
+			writeBitmap(bitmap, current, true)
+			current, bitmap = current+1, newBitmap()
+		}
 
-	// have each line draw itself to the XPM
-	for i, line := range lines {
-		if err := line.Draw(xpm, "b"); err != nil {
-			fmt.Printf("Error drawing %d'th line:\n%s\n", i, err)
+		if err := draw(shape, bitmap); err != nil {
+			fmt.Printf("Error drawing %d'th shape:\n%s\n", i, err)
 		}
+		i++
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("Error parsing input file %s:\n%s\n", input, err)
+		return
 	}
 
-	// finally, write out out resulting XPM to the output file
-	if err := xpm.WriteToFile(output); err != nil {
-		fmt.Printf("Error writing output file %s:\n%s", output, err)
+	// finally, write out out resulting XPM to the output file; that is, the
+	// selected page or the last of the pages, along with any blank ones
+	switch {
+	case page > d.PageCount():
+		fmt.Printf("Input file %s has no page %d\n", input, page)
+	case page > 0 || d.PageCount() == 1:
+		writeBitmap(bitmap, current, false)
+	default:
+		for ; current <= d.PageCount(); current++ {
+			writeBitmap(bitmap, current, true)
+			bitmap = newBitmap()
+		}
 	}
-
 }
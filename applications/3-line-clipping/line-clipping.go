@@ -3,7 +3,10 @@ package main
 import (
 	"flag" // for flag-handling related work
 	"fmt"
+	"math"
 	"os"
+	"strconv"
+	"strings"
 
 	"../../clipping"
 	ps "../../postscript"
@@ -19,39 +22,80 @@ USAGE: cmd.exe -f /path/to/input.ps -w 200 -h 200 -o /path/to/output.xpm
 	Mandatory argument.
 -w:
 	Width of the output XPM bitmap file.
-	Mandatory. Must be greater than 0.
+	Mandatory unless the input file has a %%BoundingBox, in which case the bitmap
+	covers just the box, with its lower left corner at the origin. Must be greater than 0.
 -h:
 	Height of the output XPM bitmap file.
-	Mandatory. Must be greater than 0.
+	Mandatory unless the input file has a %%BoundingBox. Must be greater than 0.
 -o:
 	Path to the output XPM bitmap file.
 	Default value is ./output.xpm
+	A multi-page input yields one output file per page, numbered before the
+	extension (i.e. ./output-1.xpm, ./output-2.xpm, ...).
+-ps:
+	Path to an optional postscript output file for the resulting lines.
+	If it ends in .eps, an Encapsulated PostScript file is written instead.
+	Numbered per page just like the XPM output file.
+-page:
+	Number of the single page of the input to render, starting from 1.
+	Its results are written to the output files as-is. By default, all pages are rendered.
+-stats:
+	Print how many of the lines of each page were accepted, rejected and clipped,
+	and how many entered and left the window through each of its edges.
+-aa:
+	Draw anti-aliased lines, blending them into the background with intermediate colors.
+	By default, lines are drawn with plain Bresenham pixels.
+
+-clip:
+	Line clipping algorithm: cs (Cohen–Sutherland), lb (Liang–Barsky), cb (Cyrus–Beck)
+	or nln (Nicholl–Lee–Nicholl).
+	Filled shapes and thick strokes are clipped with Sutherland–Hodgman whichever is chosen.
+	With cs, the lines and the segments of thin polylines (dashed ones included) are all
+	clipped in one batch, trivially accepting and rejecting them first and sharing the
+	rest among as many workers as there are CPUs; thick strokes are clipped one by one.
+	Default is cs.
+
+-wpoly:
+	Vertices of a polygonal viewing window, as a list of coordinates (i.e. "100,100 400,150 250,400").
+	The polygon may be concave, in which case lines may be visible in several pieces,
+	and filled shapes are clipped with Weiler–Atherton.
+	Overrides the window margins and the clipping algorithm when given.
 
 -wl:
 	Left margin of the viewing window.
-	Default is 0. Must be greater or equal to 0 and less than or equal to wr.
+	Default is the left of the input's %%BoundingBox, or 0. Must be greater or equal to 0 and less than or equal to wr.
 
 -wr:
 	Right margin of the viewing window.
-	Default is image width. Must be greater or equal to wl and less than or equal to the image width.
+	Default is the right of the input's %%BoundingBox, or image width. Must be greater or equal to wl and less than or equal to the image width.
 
 -wt:
 	Top margin of the viewing window.
-	Default is image height. Must be greater or equal to wb and less than or equal to the image height.
+	Default is the top of the input's %%BoundingBox, or image height. Must be greater or equal to wb and less than or equal to the image height.
 
 -wb:
 	Bottom margin of the viewing window.
-	Default is 0. Must be greater or equal to 0 and less than or equal to the image height.
+	Default is the bottom of the input's %%BoundingBox, or 0. Must be greater or equal to 0 and less than or equal to the image height.
+
+-vl, -vr, -vt, -vb:
+	Margins of the viewport of the image the viewing window is mapped to, zooming into it.
+	Any margin left out is the matching edge of the image (i.e. -vl 0 -vr width-1).
+	Must lie within the image, with vl less than vr and vb less than vt.
+	By default, the window is drawn as-is, in image coordinates.
+
+-aspect:
+	How the window is fitted into the viewport: preserve (keeping its aspect ratio, centered) or stretch.
+	Default is preserve.
 `[1:]
 
 // height command line argument
 // usage: -h UINT
-// mandatory
+// mandatory unless the input has a bounding box
 var height int
 
 // width command line argument
 // usage: -w UINT
-// mandatory
+// mandatory unless the input has a bounding box
 var width int
 
 // postscript input file command line argument
@@ -59,27 +103,291 @@ var width int
 // mandatory
 var input string
 
+// bounding box of the input, when the bitmap is sized after it; shapes are
+// moved along with it onto the bitmap as they are drawn
+var origin *ps.Box
+
 // xpm output file command line argument
 // usage: -o /path/to/file.xpm
 // default: ./output.xpm
 var output string
 
+// postscript output file command line argument
+// usage: -ps /path/to/file.ps
+// optional
+var psoutput string
+
+// page to render command line argument
+// usage: -page UINT
+// default: 0, for all the pages
+var page int
+
+// clipping statistics command line argument
+// usage: -stats
+// default: false
+var stats bool
+
+// anti-aliasing command line argument
+// usage: -aa
+// default: false
+var antialias bool
+
+// clipping algorithm command line argument
+// usage: -clip cs|lb|cb|nln
+// default: cs
+var algorithm string
+
+// polygonal window command line argument
+// usage: -wpoly "x1,y1 x2,y2 x3,y3 ..."
+// optional
+var wpoly string
+
 // window margins.
 var wl, wr, wt, wb int
 
+// viewport margins.
+var vl, vr, vt, vb int
+
+// viewport aspect command line argument
+// usage: -aspect preserve|stretch
+// default: preserve
+var aspect string
+
+// draw has the given shape draw itself to the bitmap, anti-aliased if so
+// requested, and moved along with the bounding box the bitmap is sized after
+// if any
+func draw(shape objects.Shape, bitmap *xpm.XPM) error {
+	if origin != nil {
+		shape = shape.Transform(origin)
+	}
+	if antialias {
+		return objects.DrawAntialiased(shape, bitmap, "b")
+	}
+	return shape.Draw(bitmap, "b")
+}
+
 // flaginit sets up all command line flag handling
 func flaginit() {
 	flag.IntVar(&width, "w", 0, "width of the resulting bitmap")
 	flag.IntVar(&height, "h", 0, "height of the resulting bitmap")
 	flag.StringVar(&input, "f", "", "postscript input file given for processing")
 	flag.StringVar(&output, "o", "./output.xpm", "output file for resulting bitmap")
+	flag.StringVar(&psoutput, "ps", "", "postscript output file for resulting lines")
+	flag.IntVar(&page, "page", 0, "single page of the input to render")
+	flag.BoolVar(&stats, "stats", false, "print clipping statistics")
+	flag.BoolVar(&antialias, "aa", false, "draw anti-aliased lines")
+	flag.StringVar(&algorithm, "clip", "cs", "line clipping algorithm (cs, lb, cb or nln)")
+	flag.StringVar(&wpoly, "wpoly", "", "vertices of a polygonal viewing window")
 	flag.IntVar(&wl, "wl", 0, "left margin of the viewing window")
-	flag.IntVar(&wr, "wr", width, "right margin of the viewing window")
-	flag.IntVar(&wt, "wt", height, "top margin of the viewing window")
+	flag.IntVar(&wr, "wr", 0, "right margin of the viewing window")
+	flag.IntVar(&wt, "wt", 0, "top margin of the viewing window")
 	flag.IntVar(&wb, "wb", 0, "bottom margin of the viewing window")
+	flag.IntVar(&vl, "vl", 0, "left margin of the viewport")
+	flag.IntVar(&vr, "vr", 0, "right margin of the viewport")
+	flag.IntVar(&vt, "vt", 0, "top margin of the viewport")
+	flag.IntVar(&vb, "vb", 0, "bottom margin of the viewport")
+	flag.StringVar(&aspect, "aspect", "preserve", "viewport aspect (preserve or stretch)")
 	flag.Parse()
 }
 
+// windowinit fills in the margins of the viewing window which were not given
+// on the command line, either from the given bounding box or, if there is
+// none, from the dimensions of the image
+func windowinit(bounds *ps.Box) {
+	// gather which of the margins were explicitly set
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	l, b, r, t := 0, 0, width, height
+	if bounds != nil {
+		l = int(bounds.LLX)
+		b = int(bounds.LLY)
+		r = int(math.Ceil(bounds.URX))
+		t = int(math.Ceil(bounds.URY))
+	}
+
+	if !set["wl"] {
+		wl = l
+	}
+	if !set["wb"] {
+		wb = b
+	}
+	if !set["wr"] {
+		wr = r
+	}
+	if !set["wt"] {
+		wt = t
+	}
+}
+
+// parsePolygon parses the vertices of a polygonal window out of a list of
+// coordinates separated by commas or whitespace
+func parsePolygon(list string) ([]*objects.Point, error) {
+	fields := strings.Fields(strings.Replace(list, ",", " ", -1))
+	if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("Odd number of coordinates in polygon %q", list)
+	}
+
+	points := []*objects.Point{}
+	for i := 0; i < len(fields); i += 2 {
+		x, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, err
+		}
+		y, err := strconv.ParseFloat(fields[i+1], 64)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, objects.NewPoint(x, y))
+	}
+	return points, nil
+}
+
+// newClipper returns the LineClipper for the viewing window implementing the
+// selected clipping algorithm, or for the polygonal window if one was given
+func newClipper() (clipping.LineClipper, error) {
+	if wpoly != "" {
+		points, err := parsePolygon(wpoly)
+		if err != nil {
+			return nil, err
+		}
+		return clipping.NewPolygonWindow(points...)
+	}
+
+	minx, miny, maxx, maxy := float64(wl), float64(wb), float64(wr), float64(wt)
+
+	switch algorithm {
+	case "cs":
+		return clipping.NewWindow(minx, miny, maxx, maxy), nil
+	case "lb":
+		return clipping.NewLiangBarsky(minx, miny, maxx, maxy), nil
+	case "cb":
+		return clipping.NewRectCyrusBeck(minx, miny, maxx, maxy), nil
+	case "nln":
+		return clipping.NewNichollLeeNicholl(minx, miny, maxx, maxy), nil
+	}
+	return nil, fmt.Errorf("Unknown clipping algorithm %q", algorithm)
+}
+
+// newViewport returns the Viewport the given window is mapped to, or nil if
+// none of its margins were given on the command line
+// Its margins which were not given are the outermost pixels of the image
+func newViewport(window clipping.LineClipper) (*clipping.Viewport, error) {
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	if !set["vl"] && !set["vr"] && !set["vt"] && !set["vb"] {
+		return nil, nil
+	}
+
+	// the pixels on the edges of the image are centered on them
+	if !set["vr"] {
+		vr = width - 1
+	}
+	if !set["vt"] {
+		vt = height - 1
+	}
+	if vl < 0 || vb < 0 || vr >= width || vt >= height {
+		return nil, fmt.Errorf("Viewport [(%d, %d) - (%d, %d)] out of the image", vl, vb, vr, vt)
+	}
+
+	var mode clipping.AspectMode
+	switch aspect {
+	case "preserve":
+		mode = clipping.Preserve
+	case "stretch":
+		mode = clipping.Stretch
+	default:
+		return nil, fmt.Errorf("Unknown viewport aspect %q", aspect)
+	}
+
+	return clipping.NewViewport(window, float64(vl), float64(vb), float64(vr), float64(vt), mode)
+}
+
+// printStats clips all the lines making up the outlines of the given shapes
+// against the window one by one, printing out statistics of the results
+func printStats(shapes []objects.Shape, clipper clipping.LineClipper) {
+	rc, ok := clipper.(clipping.ResultClipper)
+	if !ok {
+		return
+	}
+
+	s := clipping.NewClipStats()
+	for _, shape := range shapes {
+		var lines []*objects.Line
+		switch sh := shape.(type) {
+		case *objects.Line:
+			lines = []*objects.Line{sh}
+		case *objects.Polyline:
+			lines = sh.Lines()
+		case *objects.Polygon:
+			lines = sh.Lines()
+		}
+
+		for _, line := range lines {
+			r, err := rc.ClipLineResult(line)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			s.Add(r)
+		}
+	}
+	fmt.Println(s)
+}
+
+// render clips the given shapes of a single page against the window and draws
+// them to a new bitmap, which is written out to the given XPM file
+// If there is a viewport, the visible shapes are mapped to it first
+// The clipped shapes are also written out to the given postscript file, unless
+// its name is empty
+func render(shapes []objects.Shape, clipper clipping.LineClipper, viewport *clipping.Viewport, xpmfile, psfile string) {
+	// create XPM struct to be worked on
+	xpm := xpm.NewXPM(width, height, 1)
+
+	// add our preffered color for all subsequent line designs
+	// in this case, 100% blue balance
+	xpm.AddColor(0, 0, 255, "b")
+
+	if stats {
+		printStats(shapes, clipper)
+	}
+
+	// filter and get all clipped shapes, in image coordinates:
+	var clipped []objects.Shape
+	var err error
+	if viewport != nil {
+		clipped, err = viewport.ClipShapes(shapes)
+	} else {
+		clipped, err = clipping.ClipShapes(clipper, shapes)
+	}
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	// have each clipped shape draw itself to the XPM
+	for i, shape := range clipped {
+		if err := draw(shape, xpm); err != nil {
+			fmt.Printf("Error drawing %d'th shape:\n%s\n", i, err)
+		}
+	}
+
+	// write out the resulting shapes as vectors if so requested
+	if psfile != "" {
+		if err := ps.WriteFile(psfile, clipped); err != nil {
+			fmt.Printf("Error writing postscript output file %s:\n%s\n", psfile, err)
+		}
+	}
+
+	// finally, write out out resulting XPM to the output file
+	if err := xpm.WriteToFile(xpmfile); err != nil {
+		fmt.Printf("Error writing output file %s:\n%s", xpmfile, err)
+	}
+}
+
 //			Assignment 3:
 // Write a program which takes some command line aruments and parses a provided
 // input file which *exclusively* contains postscript line definitions,
@@ -91,55 +399,89 @@ func main() {
 	// initialize all command line flags
 	flaginit()
 
+	// read the input's metadata; without explicit dimensions,
+	// the bitmap is sized after the input's bounding box
+	var dsc *ps.DSC
+	if input != "" {
+		dsc, err = ps.ReadDSCFile(input)
+		if err != nil {
+			fmt.Printf("Error reading input file %s:\n%s\n", input, err)
+			return
+		}
+	}
+	if dsc != nil && dsc.Bounds() != nil && (width <= 0 || height <= 0) {
+		origin = dsc.Bounds()
+		width, height = origin.Size()
+	}
+	if dsc != nil {
+		for _, w := range dsc.Warnings {
+			fmt.Printf("Warning: %s\n", w)
+		}
+	}
+
 	// check all arguments
-	if height <= 0 || width <= 0 || input == "" {
+	if height <= 0 || width <= 0 || input == "" || page < 0 {
 		fmt.Println(usage)
 		os.Exit(1)
 	}
+
+	// default the window to the input's bounding box
+	windowinit(dsc.Bounds())
 	if wl > wr || wb > wt {
 		fmt.Println(usage)
 		os.Exit(2)
 	}
 
-	// create XPM struct to be worked on
-	xpm := xpm.NewXPM(width, height, 1)
-
-	// create Window struct
-	win := clipping.NewWindow(wl, wb, wr, wt)
+	// create the clipper for the window
+	clipper, err := newClipper()
+	if err != nil {
+		fmt.Println(err)
+		fmt.Println(usage)
+		os.Exit(2)
+	}
 
-	// add our preffered color for all subsequent line designs
-	// in this case, 100% blue balance
-	xpm.AddColor(0, 0, 255, "b")
+	// and the viewport it is mapped to, if any
+	viewport, err := newViewport(clipper)
+	if err != nil {
+		fmt.Println(err)
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+	if viewport != nil {
+		// which maps the window onto the bitmap by itself
+		origin = nil
+	}
 
-	// parse the input file
-	lines, err := ps.ParseFile(input)
+	// parse the input file, page by page
+	pages, err := ps.ParsePages(input)
 	if err != nil {
 		fmt.Printf("Error parsing input file %s:\n%s\n", input, err)
 		return
 	}
-
-	// filter and get all clipped lines:
-	clipped := []*objects.Line{}
-	for i, line := range lines {
-		cl, err := win.ClipLine(line)
-		if err != nil {
-			fmt.Printf("Error clipping %d'th line:\n%s\n", i, err)
-		}
-		if cl != nil {
-			clipped = append(clipped, cl)
-		}
+	if page > len(pages) {
+		fmt.Printf("Input file %s has no page %d\n", input, page)
+		return
 	}
 
-	// have each clipped line draw itself to the XPM
-	for i, line := range clipped {
-		if err := line.Draw(xpm, "b"); err != nil {
-			fmt.Printf("Error drawing %d'th line:\n%s\n", i, err)
-		}
+	// assemble the chains of Lines the inputs are mostly made up of
+	// into Polylines
+	for i := range pages {
+		pages[i] = objects.JoinShapes(pages[i])
 	}
 
-	// finally, write out out resulting XPM to the output file
-	if err := xpm.WriteToFile(output); err != nil {
-		fmt.Printf("Error writing output file %s:\n%s", output, err)
+	// render the selected page, or else every page to its own numbered files
+	switch {
+	case page > 0:
+		render(pages[page-1], clipper, viewport, output, psoutput)
+	case len(pages) == 1:
+		render(pages[0], clipper, viewport, output, psoutput)
+	default:
+		for i, shapes := range pages {
+			psfile := ""
+			if psoutput != "" {
+				psfile = ps.PageFilename(psoutput, i+1)
+			}
+			render(shapes, clipper, viewport, ps.PageFilename(output, i+1), psfile)
+		}
 	}
-
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	ps "../../postscript"
+	"../../postscript/objects"
 	"../../transformations/twod"
 	"../../xpm"
 )
@@ -17,25 +18,38 @@ USAGE: cmd.exe -f /path/to/input.ps -w 200 -h 200 -o /path/to/output.xpm
 	Mandatory argument.
 -w:
 	Width of the output XPM bitmap file.
-	Mandatory. Must be greater than 0.
+	Mandatory unless the input file has a %%BoundingBox, in which case the bitmap
+	covers just the box, with its lower left corner at the origin. Must be greater than 0.
 -h:
 	Height of the output XPM bitmap file.
-	Mandatory. Must be greater than 0.
+	Mandatory unless the input file has a %%BoundingBox. Must be greater than 0.
 -o:
 	Path to the output XPM bitmap file.
 	Default value is ./output.xpm
+	A multi-page input yields one output file per page, numbered before the
+	extension (i.e. ./output-1.xpm, ./output-2.xpm, ...).
+-ps:
+	Path to an optional postscript output file for the resulting lines.
+	If it ends in .eps, an Encapsulated PostScript file is written instead.
+	Numbered per page just like the XPM output file.
+-page:
+	Number of the single page of the input to render, starting from 1.
+	Its results are written to the output files as-is. By default, all pages are rendered.
+-aa:
+	Draw anti-aliased lines, blending them into the background with intermediate colors.
+	By default, lines are drawn with plain Bresenham pixels.
 -t:
     Path to file defining 2d transformations.
 `[1:]
 
 // height command line argument
 // usage: -h UINT
-// mandatory
+// mandatory unless the input has a bounding box
 var height int
 
 // width command line argument
 // usage: -w UINT
-// mandatory
+// mandatory unless the input has a bounding box
 var width int
 
 // postscript input file command line argument
@@ -43,6 +57,25 @@ var width int
 // mandatory
 var input string
 
+// bounding box of the input, when the bitmap is sized after it; shapes are
+// moved along with it onto the bitmap as they are drawn
+var origin *ps.Box
+
+// postscript output file command line argument
+// usage: -ps /path/to/file.ps
+// optional
+var psoutput string
+
+// page to render command line argument
+// usage: -page UINT
+// default: 0, for all the pages
+var page int
+
+// anti-aliasing command line argument
+// usage: -aa
+// default: false
+var antialias bool
+
 // transformations file command line argument
 // usage: -t /path/to/file.tsf
 // optional
@@ -53,16 +86,69 @@ var trans string
 // default: ./output.xpm
 var output string
 
+// draw has the given shape draw itself to the bitmap, anti-aliased if so
+// requested, and moved along with the bounding box the bitmap is sized after
+// if any
+func draw(shape objects.Shape, bitmap *xpm.XPM) error {
+	if origin != nil {
+		shape = shape.Transform(origin)
+	}
+	if antialias {
+		return objects.DrawAntialiased(shape, bitmap, "b")
+	}
+	return shape.Draw(bitmap, "b")
+}
+
 // flaginit sets up all command line flag handling
 func flaginit() {
 	flag.IntVar(&width, "w", 0, "width of the resulting bitmap")
 	flag.IntVar(&height, "h", 0, "height of the resulting bitmap")
 	flag.StringVar(&input, "f", "", "postscript input file given for processing")
 	flag.StringVar(&output, "o", "./output.xpm", "output file for resulting bitmap")
+	flag.StringVar(&psoutput, "ps", "", "postscript output file for resulting lines")
+	flag.IntVar(&page, "page", 0, "single page of the input to render")
+	flag.BoolVar(&antialias, "aa", false, "draw anti-aliased lines")
 	flag.StringVar(&trans, "t", "", "transformations definition file")
 	flag.Parse()
 }
 
+// render applies the given transformations to the given shapes of a single
+// page and draws them to a new bitmap, which is written out to the given XPM file
+// The transformed shapes are also written out to the given postscript file,
+// unless its name is empty
+func render(shapes []objects.Shape, ops [][]interface{}, xpmfile, psfile string) {
+	// create XPM struct to be worked on
+	xpm := xpm.NewXPM(width, height, 1)
+
+	// add our preffered color for all subsequent line designs
+	// in this case, 100% blue balance
+	xpm.AddColor(0, 0, 255, "b")
+
+	// apply transformations if transformations file was given:
+	if trans != "" {
+		shapes = twod.ApplyTransformations(shapes, ops)
+	}
+
+	// have each shape draw itself to the XPM
+	for i, shape := range shapes {
+		if err := draw(shape, xpm); err != nil {
+			fmt.Printf("Error drawing %d'th shape:\n%s\n", i, err)
+		}
+	}
+
+	// write out the resulting shapes as vectors if so requested
+	if psfile != "" {
+		if err := ps.WriteFile(psfile, shapes); err != nil {
+			fmt.Printf("Error writing postscript output file %s:\n%s\n", psfile, err)
+		}
+	}
+
+	// finally, write out out resulting XPM to the output file
+	if err := xpm.WriteToFile(xpmfile); err != nil {
+		fmt.Printf("Error writing output file %s:\n%s", xpmfile, err)
+	}
+}
+
 //			Assignment 2:
 // Write a program which takes some command line aruments and parses a provided
 // input file which *exclusively* contains postscript line definitions,
@@ -73,45 +159,71 @@ func main() {
 	// initialize all command line flags
 	flaginit()
 
+	// read the input's metadata; without explicit dimensions,
+	// the bitmap is sized after the input's bounding box
+	var dsc *ps.DSC
+	if input != "" {
+		dsc, err = ps.ReadDSCFile(input)
+		if err != nil {
+			fmt.Printf("Error reading input file %s:\n%s\n", input, err)
+			return
+		}
+	}
+	if dsc != nil && dsc.Bounds() != nil && (width <= 0 || height <= 0) {
+		origin = dsc.Bounds()
+		width, height = origin.Size()
+	}
+	if dsc != nil {
+		for _, w := range dsc.Warnings {
+			fmt.Printf("Warning: %s\n", w)
+		}
+	}
+
 	// check all arguments
-	if height <= 0 || width <= 0 || input == "" {
+	if height <= 0 || width <= 0 || input == "" || page < 0 {
 		fmt.Println(usage)
 		return
 	}
 
-	// create XPM struct to be worked on
-	xpm := xpm.NewXPM(width, height, 1)
-
-	// add our preffered color for all subsequent line designs
-	// in this case, 100% blue balance
-	xpm.AddColor(0, 0, 255, "b")
-
-	// parse the input file
-	lines, err := ps.ParseFile(input)
+	// parse the input file, page by page
+	pages, err := ps.ParsePages(input)
 	if err != nil {
 		fmt.Printf("Error parsing input file %s:\n%s\n", input, err)
 		return
 	}
+	if page > len(pages) {
+		fmt.Printf("Input file %s has no page %d\n", input, page)
+		return
+	}
 
-	// apply transformations if transformations file was given:
+	// parse the transformations if transformations file was given:
+	var ops [][]interface{}
 	if trans != "" {
-		ops, err := twod.ParseFile(trans)
+		ops, err = twod.ParseFile(trans)
 		if err != nil {
 			fmt.Printf("Error parsing transformations file %s: \n%s\n", trans, err)
 		}
-		lines = twod.ApplyTransformationsToLines(lines, ops)
 	}
 
-	// have each line draw itself to the XPM
-	for i, line := range lines {
-		if err := line.Draw(xpm, "b"); err != nil {
-			fmt.Printf("Error drawing %d'th line:\n%s\n", i, err)
-		}
+	// assemble the chains of Lines the inputs are mostly made up of
+	// into Polylines
+	for i := range pages {
+		pages[i] = objects.JoinShapes(pages[i])
 	}
 
-	// finally, write out out resulting XPM to the output file
-	if err := xpm.WriteToFile(output); err != nil {
-		fmt.Printf("Error writing output file %s:\n%s", output, err)
+	// render the selected page, or else every page to its own numbered files
+	switch {
+	case page > 0:
+		render(pages[page-1], ops, output, psoutput)
+	case len(pages) == 1:
+		render(pages[0], ops, output, psoutput)
+	default:
+		for i, shapes := range pages {
+			psfile := ""
+			if psoutput != "" {
+				psfile = ps.PageFilename(psoutput, i+1)
+			}
+			render(shapes, ops, ps.PageFilename(output, i+1), psfile)
+		}
 	}
-
 }
@@ -0,0 +1,126 @@
+package clipping
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"../postscript/objects"
+)
+
+// batchChunk is the number of consecutive lines handed to a worker of
+// ClipLines at once, large enough for the handing over to cost next to
+// nothing.
+const batchChunk = 4096
+
+// BatchClipper is implemented by the LineClippers which can clip many lines
+// at once faster than one at a time, such as Windows.
+// ClipShapes clips all the Lines among its shapes, along with the segments of
+// their thin Polylines, in a single batch whenever its LineClipper is a
+// BatchClipper.
+type BatchClipper interface {
+	LineClipper
+
+	// ClipLines returns the part of each of the given Lines inside the
+	// clipping region, at the same index, nil for the ones none of which
+	// is.
+	ClipLines(lines []*objects.Line) ([]*objects.Line, error)
+}
+
+// ClipLines clips all the given Lines against the Window, returning the
+// visible part of each of them at the same index, nil for the rejected ones,
+// satisfying the BatchClipper interface.
+// It uses as many workers as Go runs goroutines in parallel, just like
+// ClipLinesConcurrently.
+func (w *Window) ClipLines(lines []*objects.Line) ([]*objects.Line, error) {
+	return w.ClipLinesConcurrently(lines, runtime.GOMAXPROCS(0))
+}
+
+// ClipLinesConcurrently clips all the given Lines against the Window just
+// like ClipLines, with the given number of workers.
+// A first pass over all the Lines trivially accepts and rejects the ones
+// whose outcodes (see ComputeABRL) tell all or none of them is inside; the
+// ones left are clipped with the Cohen–Sutherland algorithm of ClipLine, from
+// the outcodes already computed, in chunks shared among the workers. Either
+// way, every Line is clipped exactly as ClipLine would.
+// Any error is reported along with the index of the first offending Line,
+// once all the other Lines have been clipped.
+func (w *Window) ClipLinesConcurrently(lines []*objects.Line, workers int) ([]*objects.Line, error) {
+	clipped := make([]*objects.Line, len(lines))
+
+	// the ABRL codes of both ends of the Lines which do need clipping,
+	// packed together; 0 for the others, which are done with
+	codes := make([]uint8, len(lines))
+	for i, l := range lines {
+		abrl1 := w.ComputeABRL(l.A)
+		abrl2 := w.ComputeABRL(l.B)
+
+		switch {
+		case abrl1|abrl2 == 0:
+			// trivially accept
+			clipped[i] = l
+		case abrl1&abrl2 != 0:
+			// trivially reject
+		default:
+			codes[i] = uint8(abrl1<<4 | abrl2)
+		}
+	}
+
+	// each chunk only ever writes the results of its own Lines, and keeps
+	// its first error
+	errs := make([]error, (len(lines)+batchChunk-1)/batchChunk)
+	clipChunk := func(c int) {
+		end := (c + 1) * batchChunk
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		for i := c * batchChunk; i < end; i++ {
+			if codes[i] == 0 {
+				continue
+			}
+
+			cl, err := w.clipLine(lines[i], int(codes[i]>>4), int(codes[i]&15))
+			if err != nil && errs[c] == nil {
+				errs[c] = fmt.Errorf("Error clipping %d'th line: %s", i, err)
+			}
+			clipped[i] = cl
+		}
+	}
+
+	if workers > len(errs) {
+		workers = len(errs)
+	}
+	if workers <= 1 {
+		for c := range errs {
+			clipChunk(c)
+		}
+	} else {
+		chunks := make(chan int)
+		var wg sync.WaitGroup
+		for n := 0; n < workers; n++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for c := range chunks {
+					clipChunk(c)
+				}
+			}()
+		}
+
+		for c := range errs {
+			chunks <- c
+		}
+		close(chunks)
+		wg.Wait()
+	}
+
+	// the chunks go along the Lines, so the first error is that of the
+	// first Line which failed
+	for _, err := range errs {
+		if err != nil {
+			return clipped, err
+		}
+	}
+	return clipped, nil
+}
@@ -0,0 +1,107 @@
+package clipping
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+
+	"../postscript/objects"
+)
+
+func TestClipLinesConcurrently(t *testing.T) {
+	lines := append(gridLines(10000, 2), randomLines(10000, 2)...)
+	w := NewWindow(0, 0, testWidth, testHeight)
+
+	for _, workers := range []int{1, 3, 8, 100} {
+		clipped, err := w.ClipLinesConcurrently(lines, workers)
+		if err != nil {
+			t.Fatalf("%d workers: %s", workers, err)
+		}
+
+		for i, l := range lines {
+			want, _ := w.ClipLine(l)
+			if !sameLine(clipped[i], want) {
+				t.Errorf("%d workers clip %s to %v, want %v", workers, l, clipped[i], want)
+			}
+		}
+	}
+}
+
+// shapeByShape clips the given shapes one by one, as ClipShapes does for
+// LineClippers which are not BatchClippers
+func shapeByShape(c LineClipper, shapes []objects.Shape) ([]objects.Shape, error) {
+	clipped := []objects.Shape{}
+	for _, shape := range shapes {
+		parts, err := shape.Clip(c)
+		if err != nil {
+			return nil, err
+		}
+		clipped = append(clipped, parts...)
+	}
+	return clipped, nil
+}
+
+func TestClipShapesBatch(t *testing.T) {
+	random := randomLines(30, 3)
+	points := []*objects.Point{}
+	for _, l := range random {
+		points = append(points, l.A, l.B)
+	}
+
+	dashed := objects.NewPolyline(points[:20]...)
+	dashed.Stroke = &objects.Stroke{Width: 1, Dash: []float64{40, 20}}
+	closed := objects.NewPolyline(points[20:30]...)
+	closed.Closed = true
+	thick := objects.NewPolyline(points[30:40]...)
+	thick.Stroke = &objects.Stroke{Width: 9}
+
+	shapes := []objects.Shape{
+		random[0],
+		objects.NewPolyline(points[40:]...),
+		dashed,
+		random[1],
+		closed,
+		thick,
+		objects.NewCircle(objects.NewPoint(0, 0), 100),
+	}
+
+	w := NewWindow(0, 0, testWidth, testHeight)
+	got, ok := clipShapesBatch(w, shapes)
+	if !ok {
+		t.Fatal("clipShapesBatch failed")
+	}
+	want, err := shapeByShape(w, shapes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("batch yields %d shapes, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("%d'th shape is %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+// benchmarkClipLines clips the same random lines against the window all at
+// once, with the given number of workers, reporting the time taken per line
+func benchmarkClipLines(b *testing.B, workers int) {
+	lines := randomLines(100000, 1)
+	w := NewWindow(0, 0, testWidth, testHeight)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n += len(lines) {
+		w.ClipLinesConcurrently(lines, workers)
+	}
+}
+
+func BenchmarkClipLines(b *testing.B) {
+	benchmarkClipLines(b, runtime.GOMAXPROCS(0))
+}
+
+func BenchmarkClipLinesSerially(b *testing.B) {
+	benchmarkClipLines(b, 1)
+}
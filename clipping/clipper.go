@@ -0,0 +1,175 @@
+package clipping
+
+import (
+	"fmt"
+
+	"../postscript/objects"
+)
+
+// LineClipper is the common interface of all the line clipping algorithms,
+// each of which clips lines against a region of its own.
+// All LineClippers are objects.SegmentClippers, and can therefore clip any
+// shape, whether the Lines making it up stay in one piece or not;
+// those of this package are objects.PolygonClippers as well, so that filled
+// shapes are clipped as areas, and the rectangular ones objects.CurveClippers,
+// so that curves are clipped exactly. Windows are BatchClippers too, so that
+// large sets of lines are clipped all at once.
+type LineClipper interface {
+	// ClipLine returns the part of the given Line inside the clipping
+	// region, or nil if none of it is. For the regions a Line may go in
+	// and out of several times, that is the first of its visible pieces.
+	ClipLine(l *objects.Line) (*objects.Line, error)
+
+	// ClipSegments returns all the parts of the given Line inside the
+	// clipping region, in order along the Line: a single one at most for
+	// convex regions, and one per visible piece for the others.
+	ClipSegments(l *objects.Line) ([]*objects.Line, error)
+
+	// Bounds returns the smallest rectangle containing the clipping region.
+	Bounds() *objects.Rect
+}
+
+// ClipShapes clips all the given shapes with the given LineClipper, returning
+// all the visible parts of them in order.
+// If the LineClipper is a BatchClipper, all the Lines among the shapes and
+// the segments of their Polylines which are not thick are clipped at once
+// first.
+// Any error is reported along with the index of the offending shape, once
+// all the other shapes have been clipped.
+func ClipShapes(c LineClipper, shapes []objects.Shape) ([]objects.Shape, error) {
+	if bc, ok := c.(BatchClipper); ok {
+		if clipped, ok := clipShapesBatch(bc, shapes); ok {
+			return clipped, nil
+		}
+	}
+
+	var err error
+	clipped := []objects.Shape{}
+
+	for i, shape := range shapes {
+		parts, cerr := shape.Clip(c)
+		if cerr != nil && err == nil {
+			err = fmt.Errorf("Error clipping %d'th shape: %s", i, cerr)
+		}
+		clipped = append(clipped, parts...)
+	}
+
+	return clipped, err
+}
+
+// clipShapesBatch clips all the given shapes just like ClipShapes, all of
+// their Lines in a single batch: the Lines themselves, and the segments of
+// the Polylines which are not thick, which Polyline.Clip would clip one by
+// one and join back together, dash by dash for dashed ones. Any other shape
+// is clipped on its own.
+// It returns false if anything failed to clip, for ClipShapes to clip the
+// shapes one by one instead and tell which one failed.
+func clipShapesBatch(bc BatchClipper, shapes []objects.Shape) ([]objects.Shape, bool) {
+	// the chains of segments of each shape clipped in the batch, one per
+	// dash of dashed Polylines, and nil for the shapes which are not
+	chains := make([][][]*objects.Line, len(shapes))
+	lines := []*objects.Line{}
+	for i, shape := range shapes {
+		switch s := shape.(type) {
+		case *objects.Line:
+			chains[i] = [][]*objects.Line{{s}}
+		case *objects.Polyline:
+			if s.Stroke.IsThick() {
+				continue
+			}
+			chains[i] = polylineChains(s)
+		}
+
+		for _, chain := range chains[i] {
+			lines = append(lines, chain...)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, false
+	}
+
+	clippedLines, err := bc.ClipLines(lines)
+	if err != nil {
+		return nil, false
+	}
+
+	clipped := []objects.Shape{}
+	for i, shape := range shapes {
+		switch s := shape.(type) {
+		case *objects.Line:
+			if cl := clippedLines[0]; cl != nil {
+				clipped = append(clipped, cl)
+			}
+			clippedLines = clippedLines[1:]
+			continue
+		case *objects.Polyline:
+			if chains[i] == nil {
+				break
+			}
+
+			// the dashes are all solid
+			stroke := s.Stroke
+			if stroke.IsDashed() {
+				solid := *stroke
+				solid.Dash = nil
+				stroke = &solid
+			}
+
+			for _, chain := range chains[i] {
+				visible := []*objects.Line{}
+				for _, cl := range clippedLines[:len(chain)] {
+					if cl != nil {
+						visible = append(visible, cl)
+					}
+				}
+				clippedLines = clippedLines[len(chain):]
+
+				for _, joined := range objects.JoinLines(visible) {
+					joined.Stroke = stroke
+					clipped = append(clipped, joined)
+				}
+			}
+			continue
+		}
+
+		parts, err := shape.Clip(bc)
+		if err != nil {
+			return nil, false
+		}
+		clipped = append(clipped, parts...)
+	}
+	return clipped, true
+}
+
+// polylineChains returns the segments of the given Polyline, in as many
+// chains as it has dashes, or a single one if it is not dashed.
+func polylineChains(pl *objects.Polyline) [][]*objects.Line {
+	if !pl.Stroke.IsDashed() {
+		return [][]*objects.Line{pl.Lines()}
+	}
+
+	chains := [][]*objects.Line{}
+	for _, points := range pl.Stroke.Dashes(pl.Points, pl.Closed) {
+		chains = append(chains, objects.NewPolyline(points...).Lines())
+	}
+	return chains
+}
+
+// onePiece returns the visible part of a Line clipped against a convex region
+// by ClipLine as the result of ClipSegments.
+func onePiece(cl *objects.Line, err error) ([]*objects.Line, error) {
+	if err != nil || cl == nil {
+		return nil, err
+	}
+	return []*objects.Line{cl}, nil
+}
+
+// firstPiece returns the first of the visible parts of a Line clipped by
+// ClipSegments, as ClipLine does for the regions a Line may go in and out of
+// several times.
+func firstPiece(pieces []*objects.Line, err error) (*objects.Line, error) {
+	if err != nil || len(pieces) == 0 {
+		return nil, err
+	}
+	return pieces[0], nil
+}
@@ -0,0 +1,251 @@
+package clipping
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"../postscript/objects"
+)
+
+// the window all the algorithms are checked and benchmarked against
+const testWidth, testHeight = 500, 500
+
+// randomLines returns n lines with both ends anywhere within a region twice
+// as large as the window in each direction and centered on it, for lines to
+// be accepted, rejected and clipped alike
+func randomLines(n int, seed int64) []*objects.Line {
+	rng := rand.New(rand.NewSource(seed))
+	point := func() *objects.Point {
+		return objects.NewPoint(
+			(rng.Float64()*2-.5)*testWidth,
+			(rng.Float64()*2-.5)*testHeight,
+		)
+	}
+
+	lines := make([]*objects.Line, n)
+	for i := range lines {
+		lines[i] = objects.NewLine(point(), point())
+	}
+	return lines
+}
+
+// gridLines returns n lines with both ends on a coarse grid lined up with the
+// edges and corners of the window, for lines running along the edges,
+// through the corners, parallel to the axes or reduced to a point to be
+// checked as well as the random ones
+func gridLines(n int, seed int64) []*objects.Line {
+	rng := rand.New(rand.NewSource(seed))
+	xs := []float64{-.5, -.01, 0, .25, .5, .99, 1, 1.01, 1.5}
+	point := func() *objects.Point {
+		return objects.NewPoint(
+			xs[rng.Intn(len(xs))]*testWidth,
+			xs[rng.Intn(len(xs))]*testHeight,
+		)
+	}
+
+	lines := make([]*objects.Line, n)
+	for i := range lines {
+		lines[i] = objects.NewLine(point(), point())
+	}
+	return lines
+}
+
+// sameLine returns true if the two clipped lines are both nil or have the
+// same end points, up to rounding errors
+func sameLine(a, b *objects.Line) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	near := func(p, q *objects.Point) bool {
+		return math.Abs(p.X-q.X) < 1e-6 && math.Abs(p.Y-q.Y) < 1e-6
+	}
+	return near(a.A, b.A) && near(a.B, b.B)
+}
+
+// rectClippers returns all the clippers of the given rectangle, by the name
+// of their algorithm, Cohen–Sutherland first
+func rectClippers(minx, miny, maxx, maxy float64) []struct {
+	name    string
+	clipper LineClipper
+} {
+	return []struct {
+		name    string
+		clipper LineClipper
+	}{
+		{"Cohen–Sutherland", NewWindow(minx, miny, maxx, maxy)},
+		{"Liang–Barsky", NewLiangBarsky(minx, miny, maxx, maxy)},
+		{"Cyrus–Beck", NewRectCyrusBeck(minx, miny, maxx, maxy)},
+		{"Nicholl–Lee–Nicholl", NewNichollLeeNicholl(minx, miny, maxx, maxy)},
+	}
+}
+
+// checkAgainstWindow checks that the given clipper clips all the given lines
+// just like the Cohen–Sutherland algorithm of the given Window
+func checkAgainstWindow(t *testing.T, name string, c LineClipper, w *Window, lines []*objects.Line) {
+	t.Helper()
+
+	mismatches := 0
+	for _, l := range lines {
+		want, err := w.ClipLine(l)
+		if err != nil {
+			t.Fatalf("Window.ClipLine(%s): %s", l, err)
+		}
+
+		got, err := c.ClipLine(l)
+		if err != nil || !sameLine(got, want) {
+			if mismatches++; mismatches <= 10 {
+				t.Errorf("%s clips %s to %v (error %v), want %v", name, l, got, err, want)
+			}
+		}
+	}
+	if mismatches > 10 {
+		t.Errorf("%s: %d mismatches in all", name, mismatches)
+	}
+}
+
+func TestLineClippersAgree(t *testing.T) {
+	lines := append(gridLines(100000, 1), randomLines(100000, 1)...)
+
+	clippers := rectClippers(0, 0, testWidth, testHeight)
+	w := clippers[0].clipper.(*Window)
+	for _, c := range clippers[1:] {
+		checkAgainstWindow(t, c.name, c.clipper, w, lines)
+	}
+}
+
+func TestDegenerateWindows(t *testing.T) {
+	rects := [][4]float64{
+		{0, 0, 0, testHeight},
+		{0, testHeight / 2, testWidth, testHeight / 2},
+		{testWidth, testHeight, testWidth, testHeight},
+	}
+	lines := gridLines(10000, 1)
+
+	for _, r := range rects {
+		clippers := rectClippers(r[0], r[1], r[2], r[3])
+		w := clippers[0].clipper.(*Window)
+		for _, c := range clippers[1:] {
+			checkAgainstWindow(t, c.name, c.clipper, w, lines)
+		}
+	}
+}
+
+func TestClipSegments(t *testing.T) {
+	// the rectangle clippers return the one piece ClipLine does, if any
+	for _, c := range rectClippers(0, 0, testWidth, testHeight) {
+		for _, l := range gridLines(10000, 5) {
+			cl, _ := c.clipper.ClipLine(l)
+			pieces, err := c.clipper.ClipSegments(l)
+			if err != nil || len(pieces) > 1 || (len(pieces) == 1) != (cl != nil) ||
+				(cl != nil && !sameLine(pieces[0], cl)) {
+				t.Errorf("%s clips %s into %v (error %v), want just %v", c.name, l, pieces, err, cl)
+			}
+		}
+	}
+
+	// a U, which a horizontal line through its arms goes in and out of twice
+	pt := objects.NewPoint
+	u := []*objects.Point{pt(0, 0), pt(30, 0), pt(30, 30), pt(20, 30), pt(20, 10), pt(10, 10), pt(10, 30), pt(0, 30)}
+	pw, err := NewPolygonWindow(u...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := objects.NewLine(pt(-5, 20), pt(35, 20))
+	want := []*objects.Line{
+		objects.NewLine(pt(0, 20), pt(10, 20)),
+		objects.NewLine(pt(20, 20), pt(30, 20)),
+	}
+	for _, c := range []struct {
+		name    string
+		clipper LineClipper
+	}{
+		{"PolygonWindow", pw},
+		{"PathWindow", NewPathWindow(objects.NewPolygon(objects.NonZero, u))},
+		{"ClipStack", NewClipStack(NewWindow(-10, -10, 40, 40), pw)},
+	} {
+		pieces, err := c.clipper.ClipSegments(l)
+		if err != nil || len(pieces) != len(want) || !sameLine(pieces[0], want[0]) || !sameLine(pieces[1], want[1]) {
+			t.Errorf("%s clips %s into %v (error %v), want %v", c.name, l, pieces, err, want)
+		}
+
+		cl, err := c.clipper.ClipLine(l)
+		if err != nil || !sameLine(cl, want[0]) {
+			t.Errorf("%s clips %s to %v (error %v), want its first piece %v", c.name, l, cl, err, want[0])
+		}
+	}
+}
+
+func TestStarPolygon(t *testing.T) {
+	// the points of a regular pentagon of radius 10, every one of them in
+	// turn for the pentagon itself, and every other one for a pentagram
+	polygon := func(step int) []*objects.Point {
+		points := make([]*objects.Point, 5)
+		for i := range points {
+			a := math.Pi/2 + float64(i*step)*2*math.Pi/5
+			points[i] = objects.NewPoint(10*math.Cos(a), 10*math.Sin(a))
+		}
+		return points
+	}
+
+	if _, err := NewCyrusBeck(polygon(1)...); err != nil {
+		t.Errorf("pentagon: %s", err)
+	}
+	if _, err := NewCyrusBeck(polygon(2)...); err == nil {
+		t.Error("pentagram taken for a convex polygon")
+	}
+
+	pw, err := NewPolygonWindow(polygon(2)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pw.IsConvex() {
+		t.Error("pentagram window taken for a convex one")
+	}
+
+	// a line across the arms on either side of the pentagon in the middle,
+	// which is outside
+	l := objects.NewLine(objects.NewPoint(-20, 2), objects.NewPoint(20, 2))
+	pieces, err := pw.ClipSegments(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pieces) != 2 {
+		t.Fatalf("%s is clipped into %v, want 2 pieces", l, pieces)
+	}
+	for _, piece := range pieces {
+		if piece.A.X < 0 != (piece.B.X < 0) {
+			t.Errorf("%s is clipped into %v, across the middle of the pentagram", l, pieces)
+		}
+	}
+}
+
+// benchmarkClipLine clips the same random lines against the window with the
+// given clipper, one by one, reporting the time taken per line
+func benchmarkClipLine(b *testing.B, c LineClipper) {
+	lines := randomLines(100000, 1)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		c.ClipLine(lines[n%len(lines)])
+	}
+}
+
+func BenchmarkWindowClipLine(b *testing.B) {
+	benchmarkClipLine(b, NewWindow(0, 0, testWidth, testHeight))
+}
+
+func BenchmarkLiangBarskyClipLine(b *testing.B) {
+	benchmarkClipLine(b, NewLiangBarsky(0, 0, testWidth, testHeight))
+}
+
+func BenchmarkCyrusBeckClipLine(b *testing.B) {
+	benchmarkClipLine(b, NewRectCyrusBeck(0, 0, testWidth, testHeight))
+}
+
+func BenchmarkNichollLeeNichollClipLine(b *testing.B) {
+	benchmarkClipLine(b, NewNichollLeeNicholl(0, 0, testWidth, testHeight))
+}
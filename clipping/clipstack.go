@@ -0,0 +1,158 @@
+package clipping
+
+import (
+	"fmt"
+	"math"
+
+	"../postscript/objects"
+)
+
+// ClipStack is a clipping region made up of the intersection of several
+// others, which are pushed onto it and popped off of it in turn, such as the
+// nested clipping paths of postscript or overlays clipped to the windows they
+// lie in.
+// Lines are clipped against each of the regions in turn, those going in and
+// out of any of them being split into several pieces. An empty ClipStack
+// clips nothing away.
+type ClipStack struct {
+	regions []LineClipper
+}
+
+// NewClipStack generates a new ClipStack instance with the given regions
+// pushed onto it, in order.
+func NewClipStack(regions ...LineClipper) *ClipStack {
+	return &ClipStack{regions: append([]LineClipper{}, regions...)}
+}
+
+// Push pushes the given region onto the ClipStack, so that only what lies
+// inside of it as well as of all the others is visible.
+func (s *ClipStack) Push(region LineClipper) {
+	s.regions = append(s.regions, region)
+}
+
+// Pop pops the region pushed last off the ClipStack and returns it, or nil
+// if the ClipStack is empty.
+func (s *ClipStack) Pop() LineClipper {
+	n := len(s.regions)
+	if n == 0 {
+		return nil
+	}
+
+	region := s.regions[n-1]
+	s.regions = s.regions[:n-1]
+	return region
+}
+
+// Top returns the region pushed last onto the ClipStack, or nil if it is
+// empty.
+func (s *ClipStack) Top() LineClipper {
+	if n := len(s.regions); n > 0 {
+		return s.regions[n-1]
+	}
+	return nil
+}
+
+// Depth returns the number of regions on the ClipStack.
+func (s *ClipStack) Depth() int {
+	return len(s.regions)
+}
+
+// Regions returns the regions on the ClipStack, from the first pushed to the
+// last.
+func (s *ClipStack) Regions() []LineClipper {
+	return s.regions
+}
+
+// Copy returns a new ClipStack with the same regions, which can be pushed
+// onto and popped off of without affecting this one.
+func (s *ClipStack) Copy() *ClipStack {
+	return NewClipStack(s.regions...)
+}
+
+// String satisfies fmt.Stringer.
+func (s *ClipStack) String() string {
+	return fmt.Sprintf("<clip stack of %d regions within %s>", len(s.regions), s.Bounds())
+}
+
+// Bounds returns the intersection of the rectangles containing each of the
+// regions, satisfying the LineClipper interface.
+// It is unbounded for an empty ClipStack, and empty, intersecting no other
+// rectangle, if the regions have nothing in common.
+func (s *ClipStack) Bounds() *objects.Rect {
+	r := objects.NewRect(math.Inf(-1), math.Inf(-1), math.Inf(1), math.Inf(1))
+	for _, region := range s.regions {
+		b := region.Bounds()
+		r.LLX = math.Max(r.LLX, b.LLX)
+		r.LLY = math.Max(r.LLY, b.LLY)
+		r.URX = math.Min(r.URX, b.URX)
+		r.URY = math.Min(r.URY, b.URY)
+	}
+	return r
+}
+
+// ClipSegments returns all the parts of the given Line inside every one of
+// the regions, in order along the Line, satisfying the
+// objects.SegmentClipper interface.
+// The Line itself is returned if it lies entirely inside.
+func (s *ClipStack) ClipSegments(l *objects.Line) ([]*objects.Line, error) {
+	pieces := []*objects.Line{l}
+	for _, region := range s.regions {
+		clipped := []*objects.Line{}
+		for _, piece := range pieces {
+			parts, err := objects.ClipSegments(region, piece)
+			if err != nil {
+				return nil, err
+			}
+			clipped = append(clipped, parts...)
+		}
+
+		pieces = clipped
+		if len(pieces) == 0 {
+			break
+		}
+	}
+	return pieces, nil
+}
+
+// ClipLine returns the part of the given Line inside every one of the
+// regions, or nil if there is none, satisfying the LineClipper interface.
+// If the Line goes in and out of them several times, that is the first of its
+// visible pieces; ClipSegments returns all of them.
+func (s *ClipStack) ClipLine(l *objects.Line) (*objects.Line, error) {
+	return firstPiece(s.ClipSegments(l))
+}
+
+// ClipPolygon returns the part of the given Polygon inside every one of the
+// regions, or nil if there is none, satisfying the objects.PolygonClipper
+// interface.
+// It is clipped against each region in turn; regions which are not
+// objects.PolygonClippers keep it whole as long as their bounds intersect
+// it, just as Polygon.Clip does. The Polygon itself is returned if it lies
+// entirely inside.
+func (s *ClipStack) ClipPolygon(p *objects.Polygon) (*objects.Polygon, error) {
+	for _, region := range s.regions {
+		pc, ok := region.(objects.PolygonClipper)
+		if !ok {
+			if b := p.Bounds(); b == nil || !region.Bounds().Intersects(b) {
+				return nil, nil
+			}
+			continue
+		}
+
+		clipped, err := pc.ClipPolygon(p)
+		if err != nil || clipped == nil {
+			return nil, err
+		}
+		p = clipped
+	}
+	return p, nil
+}
+
+// ClipShapes clips all the given shapes against the intersection of the
+// regions, returning all the visible parts of them in order, just like the
+// ClipShapes function.
+// Curves are approximated by Lines first, as the regions need not all be
+// able to clip them exactly.
+func (s *ClipStack) ClipShapes(shapes []objects.Shape) ([]objects.Shape, error) {
+	return ClipShapes(s, shapes)
+}
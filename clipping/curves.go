@@ -0,0 +1,217 @@
+package clipping
+
+import (
+	"math"
+	"sort"
+
+	"../postscript/objects"
+)
+
+// maxCurveDepth caps the subdivision of Bézier curves, so that curves running
+// along an edge of the rectangle can never be split indefinitely.
+const maxCurveDepth = 40
+
+// Interval is a range of the parameter of a curve, from T0 to T1.
+type Interval struct {
+	T0, T1 float64
+}
+
+// insideRect returns true if the given point lies within the rectangle, up to
+// the given tolerance.
+func insideRect(r *objects.Rect, p *objects.Point, tol float64) bool {
+	return p.X >= r.LLX-tol && p.X <= r.URX+tol && p.Y >= r.LLY-tol && p.Y <= r.URY+tol
+}
+
+// mergeIntervals returns the given Intervals, in order, with the ones which
+// follow on from one another merged together.
+func mergeIntervals(intervals []Interval) []Interval {
+	merged := []Interval{}
+	for _, in := range intervals {
+		if n := len(merged); n > 0 && merged[n-1].T1 == in.T0 {
+			merged[n-1].T1 = in.T1
+			continue
+		}
+		merged = append(merged, in)
+	}
+	return merged
+}
+
+// cosineCrossings returns the parameters t at which a cos t + b sin t = k,
+// within a whole turn.
+func cosineCrossings(a, b, k float64) []float64 {
+	r := math.Hypot(a, b)
+	if r == 0 || math.Abs(k) > r {
+		return nil
+	}
+
+	// a cos t + b sin t = r cos(t - phi)
+	phi := math.Atan2(b, a)
+	d := math.Acos(k / r)
+	return []float64{phi - d, phi + d}
+}
+
+// ArcIntervals returns the ranges of the parameter of the given Arc within
+// which it lies inside the rectangle, in order along it.
+// The outline of the ellipse crosses a side of the rectangle where one of its
+// coordinates, a sum of a cosine and a sine of the parameter, equals that of
+// the side; those parameters split the Arc into pieces which lie either
+// entirely inside or entirely outside, as their middles tell.
+// Ranges of an Arc going all around the ellipse may wrap past its end, in
+// which case they end beyond T1.
+func ArcIntervals(r *objects.Rect, a *objects.Arc) []Interval {
+	e := a.Ellipse
+	sin, cos := math.Sincos(e.Angle)
+
+	// x(t) = cx + ax cos t + bx sin t, and likewise for y
+	ax, bx := e.RX*cos, -e.RY*sin
+	ay, by := e.RX*sin, e.RY*cos
+
+	crossings := []float64{}
+	crossings = append(crossings, cosineCrossings(ax, bx, r.LLX-e.Center.X)...)
+	crossings = append(crossings, cosineCrossings(ax, bx, r.URX-e.Center.X)...)
+	crossings = append(crossings, cosineCrossings(ay, by, r.LLY-e.Center.Y)...)
+	crossings = append(crossings, cosineCrossings(ay, by, r.URY-e.Center.Y)...)
+
+	// bring the crossings within the Arc
+	ts := []float64{a.T0, a.T1}
+	for _, t := range crossings {
+		t = a.T0 + math.Mod(math.Mod(t-a.T0, 2*math.Pi)+2*math.Pi, 2*math.Pi)
+		if t > a.T0 && t < a.T1 {
+			ts = append(ts, t)
+		}
+	}
+	sort.Float64s(ts)
+
+	tol := 1e-9 * math.Max(1, math.Max(e.RX, e.RY))
+	intervals := []Interval{}
+	for i := 1; i < len(ts); i++ {
+		if ts[i] == ts[i-1] {
+			continue
+		}
+		mid := objects.NewArc(e, (ts[i-1]+ts[i])/2, (ts[i-1]+ts[i])/2).Start()
+		if insideRect(r, mid, tol) {
+			intervals = append(intervals, Interval{ts[i-1], ts[i]})
+		}
+	}
+	intervals = mergeIntervals(intervals)
+
+	// a full turn may go on from its end back into its start
+	if n := len(intervals); a.IsFull() && n > 1 && intervals[0].T0 == a.T0 && intervals[n-1].T1 == a.T1 {
+		intervals[n-1].T1 = intervals[0].T1 + (a.T1 - a.T0)
+		intervals = intervals[1:]
+	}
+	return intervals
+}
+
+// clipArc returns the parts of the given Arc inside the rectangle, or the Arc
+// itself if all of it is.
+func clipArc(r *objects.Rect, a *objects.Arc) []*objects.Arc {
+	intervals := ArcIntervals(r, a)
+	if len(intervals) == 1 && intervals[0].T0 == a.T0 && intervals[0].T1 == a.T1 {
+		return []*objects.Arc{a}
+	}
+
+	arcs := make([]*objects.Arc, len(intervals))
+	for i, in := range intervals {
+		arcs[i] = &objects.Arc{Ellipse: a.Ellipse, T0: in.T0, T1: in.T1}
+	}
+	return arcs
+}
+
+// controlBounds returns the smallest rectangle containing the control points
+// of the given curve, which contains the whole curve.
+func controlBounds(b *objects.Bezier) *objects.Rect {
+	return objects.NewPolygon(objects.NonZero, []*objects.Point{b.P0, b.P1, b.P2, b.P3}).Bounds()
+}
+
+// BezierIntervals returns the ranges of the parameter of the given Bézier
+// curve within which it lies inside the rectangle, in order along it.
+// The curve is split in halves with de Casteljau's algorithm for as long as
+// the rectangle around the control points of a part of it straddles an edge
+// of the rectangle; parts lying entirely inside or outside are accepted or
+// rejected whole, down to parts too small to matter, which go by their
+// middle.
+func BezierIntervals(r *objects.Rect, b *objects.Bezier) []Interval {
+	cb := controlBounds(b)
+	tol := 1e-9 * math.Max(1, math.Max(cb.URX-cb.LLX, cb.URY-cb.LLY))
+
+	intervals := []Interval{}
+	var subdivide func(part *objects.Bezier, t0, t1 float64, depth int)
+	subdivide = func(part *objects.Bezier, t0, t1 float64, depth int) {
+		pb := controlBounds(part)
+		switch {
+		case r.Contains(pb):
+			intervals = append(intervals, Interval{t0, t1})
+			return
+		case !r.Intersects(pb):
+			return
+		case depth >= maxCurveDepth || math.Max(pb.URX-pb.LLX, pb.URY-pb.LLY) <= tol:
+			if insideRect(r, part.PointAt(0.5), tol) {
+				intervals = append(intervals, Interval{t0, t1})
+			}
+			return
+		}
+
+		left, right := part.Split(0.5)
+		mid := (t0 + t1) / 2
+		subdivide(left, t0, mid, depth+1)
+		subdivide(right, mid, t1, depth+1)
+	}
+	subdivide(b, 0, 1, 0)
+
+	return mergeIntervals(intervals)
+}
+
+// clipBezier returns the parts of the given Bézier curve inside the
+// rectangle, or the curve itself if all of it is.
+func clipBezier(r *objects.Rect, b *objects.Bezier) []*objects.Bezier {
+	intervals := BezierIntervals(r, b)
+	if len(intervals) == 1 && intervals[0].T0 == 0 && intervals[0].T1 == 1 {
+		return []*objects.Bezier{b}
+	}
+
+	curves := make([]*objects.Bezier, len(intervals))
+	for i, in := range intervals {
+		curves[i] = b.Sub(in.T0, in.T1)
+	}
+	return curves
+}
+
+// ClipArc returns the parts of the given Arc inside the Window, in order
+// along it, satisfying the objects.CurveClipper interface.
+// The points where the Arc crosses the edges of the Window are found
+// exactly, as ArcIntervals does.
+func (w *Window) ClipArc(a *objects.Arc) ([]*objects.Arc, error) {
+	return clipArc(w.Bounds(), a), nil
+}
+
+// ClipBezier returns the parts of the given Bézier curve inside the Window,
+// in order along it, satisfying the objects.CurveClipper interface.
+// The curve is subdivided as BezierIntervals does.
+func (w *Window) ClipBezier(b *objects.Bezier) ([]*objects.Bezier, error) {
+	return clipBezier(w.Bounds(), b), nil
+}
+
+// ClipArc returns the parts of the given Arc inside the rectangle, just like
+// Window.ClipArc.
+func (lb *LiangBarsky) ClipArc(a *objects.Arc) ([]*objects.Arc, error) {
+	return clipArc(lb.Bounds(), a), nil
+}
+
+// ClipBezier returns the parts of the given Bézier curve inside the
+// rectangle, just like Window.ClipBezier.
+func (lb *LiangBarsky) ClipBezier(b *objects.Bezier) ([]*objects.Bezier, error) {
+	return clipBezier(lb.Bounds(), b), nil
+}
+
+// ClipArc returns the parts of the given Arc inside the rectangle, just like
+// Window.ClipArc.
+func (nln *NichollLeeNicholl) ClipArc(a *objects.Arc) ([]*objects.Arc, error) {
+	return clipArc(nln.Bounds(), a), nil
+}
+
+// ClipBezier returns the parts of the given Bézier curve inside the
+// rectangle, just like Window.ClipBezier.
+func (nln *NichollLeeNicholl) ClipBezier(b *objects.Bezier) ([]*objects.Bezier, error) {
+	return clipBezier(nln.Bounds(), b), nil
+}
@@ -0,0 +1,247 @@
+package clipping
+
+import (
+	"fmt"
+	"math"
+
+	"../postscript/objects"
+)
+
+// CyrusBeck clips lines against an arbitrary convex polygon with the
+// parametric Cyrus–Beck algorithm.
+type CyrusBeck struct {
+	// the vertices of the polygon, counter-clockwise
+	vertices []*objects.Point
+
+	// the inward normal of the edge starting at each vertex
+	normals []*objects.Point
+
+	// the edges of the polygon as numbered by the caller making up the
+	// edge starting at each vertex, several if it runs along collinear
+	// ones, and the vertices they were given, which number them
+	edges [][]Edge
+	given []*objects.Point
+}
+
+// cross returns the z component of the cross product of the vectors from o to
+// a and from o to b; positive if o, a and b turn counter-clockwise.
+func cross(o, a, b *objects.Point) float64 {
+	return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+}
+
+// NewCyrusBeck generates a new CyrusBeck instance clipping against the convex
+// polygon with the given vertices, in either direction.
+// Returns an error if the polygon is not convex, intersects itself or has no
+// area.
+func NewCyrusBeck(vertices ...*objects.Point) (*CyrusBeck, error) {
+	// repeated and collinear vertices add nothing; the index of the
+	// others among the given ones is kept along
+	distinct := []int{}
+	same := func(i, j int) bool {
+		return vertices[i].X == vertices[j].X && vertices[i].Y == vertices[j].Y
+	}
+	for i := range vertices {
+		if n := len(distinct); n == 0 || !same(i, distinct[n-1]) {
+			distinct = append(distinct, i)
+		}
+	}
+	if n := len(distinct); n > 1 && same(distinct[0], distinct[n-1]) {
+		distinct = distinct[:n-1]
+	}
+
+	idx := []int{}
+	for i, v := range distinct {
+		prev := distinct[(i+len(distinct)-1)%len(distinct)]
+		next := distinct[(i+1)%len(distinct)]
+		if cross(vertices[prev], vertices[v], vertices[next]) != 0 {
+			idx = append(idx, v)
+		}
+	}
+	if len(idx) < 3 {
+		return nil, fmt.Errorf("Degenerate clipping polygon")
+	}
+
+	pts := make([]*objects.Point, len(idx))
+	for i, v := range idx {
+		pts[i] = vertices[v]
+	}
+
+	// all the turns have to go the same way
+	sign := cross(pts[len(pts)-1], pts[0], pts[1])
+	for i := range pts {
+		c := cross(pts[i], pts[(i+1)%len(pts)], pts[(i+2)%len(pts)])
+		if c*sign < 0 {
+			return nil, fmt.Errorf("Non-convex clipping polygon")
+		}
+	}
+
+	// and they have to go round just once, which stars such as pentagrams
+	// do several times
+	turning := 0.0
+	for i := range pts {
+		a, b, c := pts[i], pts[(i+1)%len(pts)], pts[(i+2)%len(pts)]
+		turning += math.Atan2(cross(a, b, c), (b.X-a.X)*(c.X-b.X)+(b.Y-a.Y)*(c.Y-b.Y))
+	}
+	if math.Abs(math.Abs(turning)-2*math.Pi) > 1e-6 {
+		return nil, fmt.Errorf("Self-intersecting clipping polygon")
+	}
+
+	if sign < 0 {
+		for i, j := 0, len(pts)-1; i < j; i, j = i+1, j-1 {
+			pts[i], pts[j] = pts[j], pts[i]
+			idx[i], idx[j] = idx[j], idx[i]
+		}
+	}
+
+	cb := &CyrusBeck{
+		vertices: pts,
+		normals:  make([]*objects.Point, len(pts)),
+		edges:    make([][]Edge, len(pts)),
+		given:    vertices,
+	}
+	for i, v := range pts {
+		w := pts[(i+1)%len(pts)]
+		cb.normals[i] = objects.NewPoint(v.Y-w.Y, w.X-v.X)
+
+		// the given edges from one vertex to the next, going the way
+		// they were given
+		from, to := idx[i], idx[(i+1)%len(idx)]
+		if sign < 0 {
+			from, to = to, from
+		}
+		for e := from; e != to; e = (e + 1) % len(vertices) {
+			if !same(e, (e+1)%len(vertices)) {
+				cb.edges[i] = append(cb.edges[i], Edge(e))
+			}
+		}
+	}
+	return cb, nil
+}
+
+// NewRectCyrusBeck generates a new CyrusBeck instance clipping against the
+// given axis-aligned rectangle.
+// Unlike NewCyrusBeck, it accepts rectangles of no width or height, just as
+// NewWindow does: the normals of their edges are the axes whatever the length
+// of those, so that lines lying along such a rectangle are still clipped to
+// it.
+func NewRectCyrusBeck(minx, miny, maxx, maxy float64) *CyrusBeck {
+	return &CyrusBeck{
+		vertices: []*objects.Point{
+			objects.NewPoint(minx, miny), objects.NewPoint(maxx, miny),
+			objects.NewPoint(maxx, maxy), objects.NewPoint(minx, maxy),
+		},
+		normals: []*objects.Point{
+			objects.NewPoint(0, 1), objects.NewPoint(-1, 0),
+			objects.NewPoint(0, -1), objects.NewPoint(1, 0),
+		},
+		edges: [][]Edge{{BottomEdge}, {RightEdge}, {TopEdge}, {LeftEdge}},
+	}
+}
+
+// Vertices returns the vertices of the polygon lines are clipped against,
+// counter-clockwise.
+func (cb *CyrusBeck) Vertices() []*objects.Point {
+	return cb.vertices
+}
+
+// Bounds returns the smallest rectangle containing the polygon lines are
+// clipped against, satisfying the LineClipper interface.
+func (cb *CyrusBeck) Bounds() *objects.Rect {
+	return objects.NewPolygon(objects.NonZero, cb.vertices).Bounds()
+}
+
+// ClipLine returns the part of the given Line inside the polygon, or nil if
+// none of it is, satisfying the LineClipper interface.
+// Writing the Line as A + t(B - A), the sign of the dot product of each
+// edge's inward normal with the line's direction tells whether the line
+// enters or leaves the polygon through it, bounding t from below or from
+// above. The visible part is what remains of the [0, 1] interval.
+func (cb *CyrusBeck) ClipLine(l *objects.Line) (*objects.Line, error) {
+	cl, _, _ := cb.clipEdges(l)
+	return cl, nil
+}
+
+// ClipSegments returns the part of the given Line inside the polygon, if any,
+// as the single piece it is, satisfying the LineClipper interface.
+func (cb *CyrusBeck) ClipSegments(l *objects.Line) ([]*objects.Line, error) {
+	return onePiece(cb.ClipLine(l))
+}
+
+// clipEdges clips the given Line just like ClipLine, along with the indices
+// of the vertices starting the edges it enters and leaves the polygon
+// through; -1 for its ends which are inside.
+func (cb *CyrusBeck) clipEdges(l *objects.Line) (*objects.Line, int, int) {
+	dx := l.B.X - l.A.X
+	dy := l.B.Y - l.A.Y
+
+	t0, t1 := 0.0, 1.0
+	e0, e1 := -1, -1
+	for i, n := range cb.normals {
+		v := cb.vertices[i]
+
+		// num is positive if A is inside of the edge, and den if the line
+		// goes inwards
+		num := n.X*(l.A.X-v.X) + n.Y*(l.A.Y-v.Y)
+		den := n.X*dx + n.Y*dy
+
+		if den == 0 {
+			// parallel to the edge, and entirely outside of it
+			if num < 0 {
+				return nil, -1, -1
+			}
+			continue
+		}
+
+		t := -num / den
+		if den > 0 {
+			// entering
+			if t > t0 {
+				t0, e0 = t, i
+			}
+		} else if t < t1 {
+			// leaving
+			t1, e1 = t, i
+		}
+		if t0 > t1 {
+			return nil, -1, -1
+		}
+	}
+
+	if e0 < 0 && e1 < 0 {
+		// trivially accept
+		return l, -1, -1
+	}
+
+	return objects.NewLine(
+		objects.NewPoint(l.A.X+t0*dx, l.A.Y+t0*dy),
+		objects.NewPoint(l.A.X+t1*dx, l.A.Y+t1*dy),
+	), e0, e1
+}
+
+// edge returns the edge, as numbered by the caller, the given point lies on
+// along the edge starting at the vertex with the given index, or NoEdge for
+// a negative index.
+// Where collinear edges were merged into that one, the point is looked up
+// among them.
+func (cb *CyrusBeck) edge(p *objects.Point, i int) Edge {
+	if i < 0 {
+		return NoEdge
+	}
+
+	edges := cb.edges[i]
+	if len(edges) == 1 {
+		return edges[0]
+	}
+
+	lines := make([]*objects.Line, len(edges))
+	for j, e := range edges {
+		lines[j] = objects.NewLine(cb.given[e], cb.given[(int(e)+1)%len(cb.given)])
+	}
+	return edges[nearestEdge(p, lines)]
+}
+
+// ClipShapes clips all the given shapes against the polygon, returning all
+// the visible parts of them in order, just like the ClipShapes function.
+func (cb *CyrusBeck) ClipShapes(shapes []objects.Shape) ([]objects.Shape, error) {
+	return ClipShapes(cb, shapes)
+}
@@ -0,0 +1,133 @@
+package clipping
+
+import (
+	"../postscript/objects"
+)
+
+// LiangBarsky clips lines against an axis-aligned rectangle with the
+// parametric Liang–Barsky algorithm.
+// Unlike the Cohen–Sutherland algorithm of Window, it never computes any
+// intersection other than the (at most two) final ones.
+type LiangBarsky struct {
+	minx, miny, maxx, maxy float64
+}
+
+// NewLiangBarsky generates a new LiangBarsky instance clipping against the
+// given rectangle.
+func NewLiangBarsky(minx, miny, maxx, maxy float64) *LiangBarsky {
+	return &LiangBarsky{minx: minx, miny: miny, maxx: maxx, maxy: maxy}
+}
+
+// Bounds returns the rectangle lines are clipped against, satisfying the
+// LineClipper interface.
+func (lb *LiangBarsky) Bounds() *objects.Rect {
+	return objects.NewRect(lb.minx, lb.miny, lb.maxx, lb.maxy)
+}
+
+// ClipLine returns the part of the given Line inside the rectangle, or nil if
+// none of it is, satisfying the LineClipper interface.
+// Writing the Line as A + t(B - A), each edge of the rectangle bounds t from
+// one side: from below where the line enters the rectangle through it, and
+// from above where it leaves through it. The visible part is what remains of
+// the [0, 1] interval.
+func (lb *LiangBarsky) ClipLine(l *objects.Line) (*objects.Line, error) {
+	cl, _, _ := lb.clipEdges(l)
+	return cl, nil
+}
+
+// ClipSegments returns the part of the given Line inside the rectangle, if any,
+// as the single piece it is, satisfying the LineClipper interface.
+func (lb *LiangBarsky) ClipSegments(l *objects.Line) ([]*objects.Line, error) {
+	return onePiece(lb.ClipLine(l))
+}
+
+// rectSides are the sides of rectangles, in the order of the edges of
+// LiangBarsky.clipEdges.
+var rectSides = [4]Edge{LeftEdge, RightEdge, BottomEdge, TopEdge}
+
+// clipEdges clips the given Line just like ClipLine, along with the edges it
+// enters and leaves the rectangle through; NoEdge for its ends which are
+// inside.
+func (lb *LiangBarsky) clipEdges(l *objects.Line) (*objects.Line, Edge, Edge) {
+	dx := l.B.X - l.A.X
+	dy := l.B.Y - l.A.Y
+
+	// p is the projection of the line onto each edge's outward normal, and q
+	// the distance from A to that edge, on the inner side if positive
+	edges := [4]struct{ p, q float64 }{
+		{-dx, l.A.X - lb.minx},
+		{dx, lb.maxx - l.A.X},
+		{-dy, l.A.Y - lb.miny},
+		{dy, lb.maxy - l.A.Y},
+	}
+
+	t0, t1 := 0.0, 1.0
+	e0, e1 := -1, -1
+	for i, e := range edges {
+		if e.p == 0 {
+			// parallel to the edge, and entirely outside of it
+			if e.q < 0 {
+				return nil, NoEdge, NoEdge
+			}
+			continue
+		}
+
+		t := e.q / e.p
+		if e.p < 0 {
+			// entering
+			if t > t1 {
+				return nil, NoEdge, NoEdge
+			}
+			if t > t0 {
+				t0, e0 = t, i
+			}
+		} else {
+			// leaving
+			if t < t0 {
+				return nil, NoEdge, NoEdge
+			}
+			if t < t1 {
+				t1, e1 = t, i
+			}
+		}
+	}
+
+	if e0 < 0 && e1 < 0 {
+		// trivially accept
+		return l, NoEdge, NoEdge
+	}
+
+	a, b := l.A, l.B
+	entry, exit := NoEdge, NoEdge
+	if e0 >= 0 {
+		a = lb.snap(objects.NewPoint(l.A.X+t0*dx, l.A.Y+t0*dy), e0)
+		entry = rectSides[e0]
+	}
+	if e1 >= 0 {
+		b = lb.snap(objects.NewPoint(l.A.X+t1*dx, l.A.Y+t1*dy), e1)
+		exit = rectSides[e1]
+	}
+	return objects.NewLine(a, b), entry, exit
+}
+
+// snap puts the given intersection exactly onto the edge with the given index
+// so that rounding errors can never leave it outside of the rectangle.
+func (lb *LiangBarsky) snap(p *objects.Point, edge int) *objects.Point {
+	switch edge {
+	case 0:
+		p.X = lb.minx
+	case 1:
+		p.X = lb.maxx
+	case 2:
+		p.Y = lb.miny
+	case 3:
+		p.Y = lb.maxy
+	}
+	return p
+}
+
+// ClipShapes clips all the given shapes against the rectangle, returning all
+// the visible parts of them in order, just like the ClipShapes function.
+func (lb *LiangBarsky) ClipShapes(shapes []objects.Shape) ([]objects.Shape, error) {
+	return ClipShapes(lb, shapes)
+}
@@ -0,0 +1,237 @@
+package clipping
+
+import (
+	"math"
+
+	"../postscript/objects"
+)
+
+// NichollLeeNicholl clips lines against an axis-aligned rectangle with the
+// Nicholl–Lee–Nicholl algorithm.
+// The plane around the rectangle is divided into regions by the lines through
+// its edges, and further around each end of the Line by the rays from it to
+// the corners of the rectangle. Telling which of those the Line goes through
+// takes nothing but comparisons, after which exactly the intersections with
+// the edges it enters and leaves through are computed; unlike the
+// Cohen–Sutherland algorithm of Window, it never computes one which is
+// clipped away again.
+type NichollLeeNicholl struct {
+	minx, miny, maxx, maxy float64
+}
+
+// NewNichollLeeNicholl generates a new NichollLeeNicholl instance clipping
+// against the given rectangle.
+func NewNichollLeeNicholl(minx, miny, maxx, maxy float64) *NichollLeeNicholl {
+	return &NichollLeeNicholl{minx: minx, miny: miny, maxx: maxx, maxy: maxy}
+}
+
+// Bounds returns the rectangle lines are clipped against, satisfying the
+// LineClipper interface.
+func (nln *NichollLeeNicholl) Bounds() *objects.Rect {
+	return objects.NewRect(nln.minx, nln.miny, nln.maxx, nln.maxy)
+}
+
+// symmetry is one of the symmetries of an axis-aligned rectangle: the
+// coordinates are swapped first if swap is set, and then multiplied by sx and
+// sy, each 1 or -1.
+// These are exact, so that the algorithm only has to deal with lines starting
+// inside, left of or below left of the rectangle, the others being brought
+// there and back.
+type symmetry struct {
+	swap   bool
+	sx, sy float64
+}
+
+// apply returns the image of the point (x, y) through the symmetry.
+func (s symmetry) apply(x, y float64) (float64, float64) {
+	if s.swap {
+		x, y = y, x
+	}
+	return s.sx * x, s.sy * y
+}
+
+// invert returns the point whose image through the symmetry is (x, y).
+func (s symmetry) invert(x, y float64) (float64, float64) {
+	x, y = s.sx*x, s.sy*y
+	if s.swap {
+		x, y = y, x
+	}
+	return x, y
+}
+
+// symmetryOf returns the symmetry bringing the region of the given point
+// left of or below left of the rectangle, and false if the point is inside.
+func (nln *NichollLeeNicholl) symmetryOf(x, y float64) (symmetry, bool) {
+	left, right := x < nln.minx, x > nln.maxx
+	below, above := y < nln.miny, y > nln.maxy
+
+	s := symmetry{sx: 1, sy: 1}
+	switch {
+	case !left && !right && !below && !above:
+		return s, false
+	case (left || right) && (below || above):
+		// corners
+		if right {
+			s.sx = -1
+		}
+		if above {
+			s.sy = -1
+		}
+	case right:
+		s.sx = -1
+	case below:
+		s.swap = true
+	case above:
+		s.swap, s.sx = true, -1
+	}
+	return s, true
+}
+
+// orient returns the orientation of the direction (dx, dy) relative to the
+// ray from (x1, y1) to (cx, cy): positive counter-clockwise of it, negative
+// clockwise of it and 0 along it.
+func orient(x1, y1, cx, cy, dx, dy float64) float64 {
+	return (cx-x1)*dy - (cy-y1)*dx
+}
+
+// edge returns the side of the rectangle which the given side of its image
+// through the symmetry is the image of: its left or right side if vertical
+// is set, its bottom or top one otherwise, low picking the first.
+func (s symmetry) edge(vertical, low bool) Edge {
+	scale := s.sy
+	if vertical {
+		scale = s.sx
+	}
+
+	low = low == (scale > 0)
+	if vertical != s.swap {
+		if low {
+			return LeftEdge
+		}
+		return RightEdge
+	}
+	if low {
+		return BottomEdge
+	}
+	return TopEdge
+}
+
+// ClipLine returns the part of the given Line inside the rectangle, or nil if
+// none of it is, satisfying the LineClipper interface.
+func (nln *NichollLeeNicholl) ClipLine(l *objects.Line) (*objects.Line, error) {
+	cl, _, _ := nln.clipEdges(l)
+	return cl, nil
+}
+
+// ClipSegments returns the part of the given Line inside the rectangle, if any,
+// as the single piece it is, satisfying the LineClipper interface.
+func (nln *NichollLeeNicholl) ClipSegments(l *objects.Line) ([]*objects.Line, error) {
+	return onePiece(nln.ClipLine(l))
+}
+
+// clipEdges clips the given Line just like ClipLine, along with the edges it
+// enters and leaves the rectangle through; NoEdge for its ends which are
+// inside.
+func (nln *NichollLeeNicholl) clipEdges(l *objects.Line) (*objects.Line, Edge, Edge) {
+	s, outside := nln.symmetryOf(l.A.X, l.A.Y)
+	if !outside {
+		t, outside := nln.symmetryOf(l.B.X, l.B.Y)
+		if !outside {
+			// trivially accept
+			return l, NoEdge, NoEdge
+		}
+
+		// clip the reversed Line, starting outside
+		b, a, exit, entry := nln.clip(l.B, l.A, t)
+		if b == nil {
+			return nil, NoEdge, NoEdge
+		}
+		return objects.NewLine(a, b), entry, exit
+	}
+
+	a, b, entry, exit := nln.clip(l.A, l.B, s)
+	if a == nil {
+		return nil, NoEdge, NoEdge
+	}
+	return objects.NewLine(a, b), entry, exit
+}
+
+// clip returns the ends of the part of the Line from p1, outside of the
+// rectangle, to p2 inside of it, or nil ones if there is none, along with the
+// edges it enters and leaves the rectangle through.
+// The given symmetry brings p1 left of or below left of the rectangle; p2 is
+// returned as is if it is inside, with NoEdge for the edge it leaves through.
+func (nln *NichollLeeNicholl) clip(p1, p2 *objects.Point, s symmetry) (*objects.Point, *objects.Point, Edge, Edge) {
+	// the rectangle and the Line, through the symmetry
+	x0, y0 := s.apply(nln.minx, nln.miny)
+	x3, y3 := s.apply(nln.maxx, nln.maxy)
+	xl, xr := math.Min(x0, x3), math.Max(x0, x3)
+	yb, yt := math.Min(y0, y3), math.Max(y0, y3)
+
+	x1, y1 := s.apply(p1.X, p1.Y)
+	x2, y2 := s.apply(p2.X, p2.Y)
+	dx, dy := x2-x1, y2-y1
+
+	// the points where the Line crosses the vertical line at x, and the
+	// horizontal one at y, back out of the symmetry
+	atX := func(x float64) *objects.Point {
+		y := math.Max(yb, math.Min(yt, y1+(x-x1)*dy/dx))
+		return objects.NewPoint(s.invert(x, y))
+	}
+	atY := func(y float64) *objects.Point {
+		x := math.Max(xl, math.Min(xr, x1+(y-y1)*dx/dy))
+		return objects.NewPoint(s.invert(x, y))
+	}
+	inside := x2 >= xl && x2 <= xr && y2 >= yb && y2 <= yt
+
+	// the sides of the rectangle, back out of the symmetry
+	left, right := s.edge(true, true), s.edge(true, false)
+	bottom, top := s.edge(false, true), s.edge(false, false)
+
+	if y1 >= yb {
+		// left of the rectangle: the Line enters it through the left
+		// edge, between the rays to its lower and upper left corners,
+		// if at all
+		if x2 < xl || orient(x1, y1, xl, yb, dx, dy) < 0 || orient(x1, y1, xl, yt, dx, dy) > 0 {
+			return nil, nil, NoEdge, NoEdge
+		}
+
+		entry := atX(xl)
+		switch {
+		case inside:
+			return entry, p2, left, NoEdge
+		case orient(x1, y1, xr, yb, dx, dy) < 0:
+			return entry, atY(yb), left, bottom
+		case orient(x1, y1, xr, yt, dx, dy) <= 0:
+			return entry, atX(xr), left, right
+		}
+		return entry, atY(yt), left, top
+	}
+
+	// below left of the rectangle: the Line enters it between the rays to
+	// its lower right and upper left corners, if at all, through the left
+	// edge above the ray to the lower left corner and through the bottom
+	// edge below it
+	if x2 < xl || y2 < yb || orient(x1, y1, xr, yb, dx, dy) < 0 || orient(x1, y1, xl, yt, dx, dy) > 0 {
+		return nil, nil, NoEdge, NoEdge
+	}
+
+	entry, through := atY(yb), bottom
+	if orient(x1, y1, xl, yb, dx, dy) >= 0 {
+		entry, through = atX(xl), left
+	}
+
+	switch {
+	case inside:
+		return entry, p2, through, NoEdge
+	case orient(x1, y1, xr, yt, dx, dy) > 0:
+		return entry, atY(yt), through, top
+	}
+	return entry, atX(xr), through, right
+}
+
+// ClipShapes clips all the given shapes against the rectangle, returning all
+// the visible parts of them in order, just like the ClipShapes function.
+func (nln *NichollLeeNicholl) ClipShapes(shapes []objects.Shape) ([]objects.Shape, error) {
+	return ClipShapes(nln, shapes)
+}
@@ -0,0 +1,105 @@
+package clipping
+
+import (
+	"testing"
+
+	"../postscript/objects"
+)
+
+// clipTest is a Line along with what is left of it once clipped, nil if
+// nothing is
+type clipTest struct {
+	name string
+	l    *objects.Line
+	want *objects.Line
+}
+
+func TestNichollLeeNichollRandom(t *testing.T) {
+	nln := NewNichollLeeNicholl(0, 0, testWidth, testHeight)
+	w := NewWindow(0, 0, testWidth, testHeight)
+
+	for _, seed := range []int64{1, 2, 3} {
+		checkAgainstWindow(t, "Nicholl–Lee–Nicholl", nln, w, randomLines(100000, seed))
+		checkAgainstWindow(t, "Nicholl–Lee–Nicholl", nln, w, gridLines(100000, seed))
+	}
+}
+
+func TestNichollLeeNicholl(t *testing.T) {
+	// the window is [10, 20] x [10, 20]
+	line := func(x1, y1, x2, y2 float64) *objects.Line {
+		return objects.NewLine(objects.NewPoint(x1, y1), objects.NewPoint(x2, y2))
+	}
+
+	tests := []clipTest{
+		// along the edges
+		{"along the left edge", line(10, 0, 10, 30), line(10, 10, 10, 20)},
+		{"along the right edge", line(20, 30, 20, 0), line(20, 20, 20, 10)},
+		{"along the bottom edge", line(0, 10, 30, 10), line(10, 10, 20, 10)},
+		{"along the top edge", line(30, 20, 0, 20), line(20, 20, 10, 20)},
+		{"inside the top edge", line(12, 20, 18, 20), line(12, 20, 18, 20)},
+		{"along the bottom edge, outside", line(0, 10, 5, 10), nil},
+		{"just outside the left edge", line(9, 0, 9, 30), nil},
+
+		// through the corners
+		{"through the bottom left corner only", line(0, 20, 20, 0), line(10, 10, 10, 10)},
+		{"through the top right corner only", line(15, 25, 25, 15), line(20, 20, 20, 20)},
+		{"along the diagonal", line(0, 0, 30, 30), line(10, 10, 20, 20)},
+		{"along the other diagonal", line(25, 5, 5, 25), line(20, 10, 10, 20)},
+		{"from a corner outwards", line(10, 20, 0, 30), line(10, 20, 10, 20)},
+		{"from a corner inwards", line(20, 10, 15, 15), line(20, 10, 15, 15)},
+
+		// degenerate points
+		{"point inside", line(15, 15, 15, 15), line(15, 15, 15, 15)},
+		{"point on an edge", line(10, 15, 10, 15), line(10, 15, 10, 15)},
+		{"point on a corner", line(20, 20, 20, 20), line(20, 20, 20, 20)},
+		{"point outside", line(5, 15, 5, 15), nil},
+		{"point below left", line(5, 5, 5, 5), nil},
+	}
+
+	// from each of the 9 regions to the center, and to the opposite region
+	regions := []float64{5, 15, 25}
+	for _, x := range regions {
+		for _, y := range regions {
+			a := objects.NewPoint(x, y)
+			want := line(clampTest(x), clampTest(y), 15, 15)
+			tests = append(tests, clipTest{"region to the center", objects.NewLine(a, objects.NewPoint(15, 15)), want})
+
+			b := objects.NewPoint(30-x, 30-y)
+			want = line(clampTest(x), clampTest(y), clampTest(30-x), clampTest(30-y))
+			if x == 15 && y == 15 {
+				want = line(15, 15, 15, 15)
+			}
+			tests = append(tests, clipTest{"region to the opposite one", objects.NewLine(a, b), want})
+		}
+	}
+
+	nln := NewNichollLeeNicholl(10, 10, 20, 20)
+	w := NewWindow(10, 10, 20, 20)
+	for _, test := range tests {
+		got, err := nln.ClipLine(test.l)
+		if err != nil {
+			t.Errorf("%s: clipping %s: %s", test.name, test.l, err)
+			continue
+		}
+		if !sameLine(got, test.want) {
+			t.Errorf("%s: %s is clipped to %v, want %v", test.name, test.l, got, test.want)
+		}
+
+		// the reference as well, for the table to be right
+		if cs, _ := w.ClipLine(test.l); !sameLine(cs, test.want) {
+			t.Errorf("%s: Window clips %s to %v, want %v", test.name, test.l, cs, test.want)
+		}
+	}
+}
+
+// clampTest returns the given coordinate of a test line end, clamped into the
+// [10, 20] range of the test window
+func clampTest(v float64) float64 {
+	switch {
+	case v < 10:
+		return 10
+	case v > 20:
+		return 20
+	}
+	return v
+}
@@ -0,0 +1,125 @@
+package clipping
+
+import (
+	"math"
+
+	"../postscript/objects"
+)
+
+// PathWindow is a clipping region bounded by a whole Polygon: any number of
+// rings, which may be concave, nested or intersecting, with the Polygon's fill
+// rule telling the inside from the outside, such as a postscript clipping
+// path.
+// Lines are split wherever they cross an edge of any ring, as with concave
+// PolygonWindows; a Polygon with no area at all clips everything away.
+type PathWindow struct {
+	polygon *objects.Polygon
+	edges   []*objects.Line
+}
+
+// NewPathWindow generates a new PathWindow instance bounded by the given
+// Polygon.
+func NewPathWindow(p *objects.Polygon) *PathWindow {
+	return &PathWindow{polygon: p, edges: p.Lines()}
+}
+
+// Polygon returns the Polygon bounding the PathWindow.
+func (pw *PathWindow) Polygon() *objects.Polygon {
+	return pw.polygon
+}
+
+// Bounds returns the smallest rectangle containing the Polygon, satisfying
+// the LineClipper interface.
+// Without any area, it is an empty rectangle intersecting no other.
+func (pw *PathWindow) Bounds() *objects.Rect {
+	if b := pw.polygon.Bounds(); b != nil {
+		return b
+	}
+	return objects.NewRect(math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1))
+}
+
+// Contains returns true if the given point lies inside of the Polygon, as
+// its fill rule tells, or on its boundary.
+func (pw *PathWindow) Contains(p *objects.Point) bool {
+	return pw.onBoundary(p) || pw.polygon.Contains(p)
+}
+
+// onBoundary returns true if the given point lies on an edge of the Polygon.
+func (pw *PathWindow) onBoundary(p *objects.Point) bool {
+	for _, e := range pw.edges {
+		if onSegment(p, e.A, e.B) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClipSegments returns all the parts of the given Line inside the Polygon, in
+// order along the Line, satisfying the objects.SegmentClipper interface.
+func (pw *PathWindow) ClipSegments(l *objects.Line) ([]*objects.Line, error) {
+	if !pw.Bounds().Intersects(l.Bounds()) {
+		return nil, nil
+	}
+	pieces, _ := splitLine(l, pw.edges, pw.Contains)
+	return pieces, nil
+}
+
+// ClipLine returns the part of the given Line inside the Polygon, or nil if
+// none of it is, satisfying the LineClipper interface.
+// If the Line goes in and out of the Polygon several times, that is the first
+// of its visible pieces; ClipSegments returns all of them.
+func (pw *PathWindow) ClipLine(l *objects.Line) (*objects.Line, error) {
+	return firstPiece(pw.ClipSegments(l))
+}
+
+// ClipShapes clips all the given shapes against the Polygon, returning all
+// the visible parts of them in order, just like the ClipShapes function.
+func (pw *PathWindow) ClipShapes(shapes []objects.Shape) ([]objects.Shape, error) {
+	return ClipShapes(pw, shapes)
+}
+
+// ClipPolygon returns the part of the given Polygon inside the one bounding
+// the PathWindow, or nil if none of it is, satisfying the
+// objects.PolygonClipper interface.
+// The given Polygon itself is returned if all of its outline lies inside and
+// none of the PathWindow's vertices lies within it, so that no hole of the
+// PathWindow can either; otherwise it is clipped with the Weiler–Atherton
+// algorithm of Intersect, all the pieces it yields making up a single
+// Polygon.
+func (pw *PathWindow) ClipPolygon(p *objects.Polygon) (*objects.Polygon, error) {
+	if b := p.Bounds(); b == nil || !pw.Bounds().Intersects(b) {
+		return nil, nil
+	}
+
+	inside := true
+	for _, line := range p.Lines() {
+		pieces, _ := pw.ClipSegments(line)
+		if len(pieces) != 1 || pieces[0] != line {
+			inside = false
+			break
+		}
+	}
+	if inside {
+		// the vertices of the PathWindow on the Polygon's outline are
+		// no clue to its holes
+		clipped := NewPathWindow(p)
+		for _, e := range pw.edges {
+			if p.Contains(e.A) && !clipped.onBoundary(e.A) {
+				inside = false
+				break
+			}
+		}
+	}
+	if inside {
+		return p, nil
+	}
+
+	rings := [][]*objects.Point{}
+	for _, piece := range Intersect(p, pw.polygon) {
+		rings = append(rings, piece.Rings...)
+	}
+	if len(rings) == 0 {
+		return nil, nil
+	}
+	return objects.NewPolygon(objects.NonZero, rings...), nil
+}
@@ -0,0 +1,231 @@
+package clipping
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"../postscript/objects"
+)
+
+// PolygonWindow is a clipping region bounded by an arbitrary simple polygon,
+// such as a rotated rectangle or an irregular viewport.
+// Lines are clipped against convex polygons with the Cyrus–Beck algorithm.
+// Against concave ones, they are split wherever they cross an edge, keeping
+// the pieces in between which lie inside, so that a single Line may yield
+// several visible pieces.
+type PolygonWindow struct {
+	vertices []*objects.Point
+
+	// the Cyrus–Beck clipper of a convex polygon, nil for a concave one
+	convex *CyrusBeck
+}
+
+// NewPolygonWindow generates a new PolygonWindow instance bounded by the
+// polygon with the given vertices, in either direction.
+// Returns an error if the polygon has fewer than three vertices.
+func NewPolygonWindow(vertices ...*objects.Point) (*PolygonWindow, error) {
+	if len(vertices) < 3 {
+		return nil, fmt.Errorf("Clipping polygon needs at least 3 vertices, got %d", len(vertices))
+	}
+
+	pw := &PolygonWindow{vertices: vertices}
+	if cb, err := NewCyrusBeck(vertices...); err == nil {
+		pw.convex = cb
+	}
+	return pw, nil
+}
+
+// Vertices returns the vertices of the polygon bounding the PolygonWindow.
+func (pw *PolygonWindow) Vertices() []*objects.Point {
+	return pw.vertices
+}
+
+// IsConvex returns true if the polygon bounding the PolygonWindow is convex.
+func (pw *PolygonWindow) IsConvex() bool {
+	return pw.convex != nil
+}
+
+// Bounds returns the smallest rectangle containing the polygon, satisfying
+// the LineClipper interface.
+func (pw *PolygonWindow) Bounds() *objects.Rect {
+	return objects.NewPolygon(objects.NonZero, pw.vertices).Bounds()
+}
+
+// Contains returns true if the given point lies inside of the polygon or on
+// its boundary.
+// Points inside are told apart from points outside by the number of edges a
+// horizontal ray from them crosses, as with the even-odd fill rule.
+func (pw *PolygonWindow) Contains(p *objects.Point) bool {
+	inside := false
+	for i, a := range pw.vertices {
+		b := pw.vertices[(i+1)%len(pw.vertices)]
+
+		if onSegment(p, a, b) {
+			return true
+		}
+		if (a.Y > p.Y) != (b.Y > p.Y) {
+			x := a.X + (p.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+			if x > p.X {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// onSegment returns true if p lies on the segment between a and b, up to
+// rounding errors.
+func onSegment(p, a, b *objects.Point) bool {
+	length := math.Hypot(b.X-a.X, b.Y-a.Y)
+	if length == 0 {
+		return p.X == a.X && p.Y == a.Y
+	}
+
+	// the distance of p to the line through a and b, and its projection
+	// onto it relative to the segment
+	dist := math.Abs(cross(a, b, p)) / length
+	t := ((p.X-a.X)*(b.X-a.X) + (p.Y-a.Y)*(b.Y-a.Y)) / (length * length)
+	return dist < 1e-9*math.Max(1, length) && t >= 0 && t <= 1
+}
+
+// ClipSegments returns all the parts of the given Line inside the polygon, in
+// order along the Line, satisfying the objects.SegmentClipper interface.
+// The Line is split at every point it crosses an edge of the polygon, and each
+// of the resulting pieces is kept if its midpoint lies inside the polygon,
+// consecutive ones being merged back together.
+func (pw *PolygonWindow) ClipSegments(l *objects.Line) ([]*objects.Line, error) {
+	if pw.convex != nil {
+		return pw.convex.ClipSegments(l)
+	}
+
+	pieces, _ := splitLine(l, polygonEdges(pw.vertices), pw.Contains)
+	return pieces, nil
+}
+
+// splitLine returns all the parts of the given Line inside a region with the
+// given edges, in order along the Line, as told by the given inside test.
+// The Line is split at every point it crosses an edge, and each of the
+// resulting pieces is kept if its midpoint lies inside, consecutive ones being
+// merged back together; the Line itself is returned if it is all inside.
+// The indices of the edges each piece starts and ends on are returned along,
+// -1 for the ends of the Line.
+func splitLine(l *objects.Line, edges []*objects.Line, contains func(p *objects.Point) bool) ([]*objects.Line, [][2]int) {
+	dx := l.B.X - l.A.X
+	dy := l.B.Y - l.A.Y
+	at := func(t float64) *objects.Point {
+		return objects.NewPoint(l.A.X+t*dx, l.A.Y+t*dy)
+	}
+
+	// the parameters of all the points the Line crosses an edge at, writing
+	// the Line as A + t(B - A) and each edge as V + u(W - V), along with
+	// the index of the edge
+	type crossing struct {
+		t    float64
+		edge int
+	}
+	ts := []crossing{{0, -1}, {1, -1}}
+	for i, e := range edges {
+		v := e.A
+		ex, ey := e.B.X-v.X, e.B.Y-v.Y
+
+		den := dx*ey - dy*ex
+		if den == 0 {
+			// parallel edges never split the Line; collinear ones are
+			// taken care of by the inside test of the pieces
+			continue
+		}
+
+		t := ((v.X-l.A.X)*ey - (v.Y-l.A.Y)*ex) / den
+		u := ((v.X-l.A.X)*dy - (v.Y-l.A.Y)*dx) / den
+		if t > 0 && t < 1 && u >= 0 && u <= 1 {
+			ts = append(ts, crossing{t, i})
+		}
+	}
+	sort.SliceStable(ts, func(i, j int) bool {
+		return ts[i].t < ts[j].t
+	})
+
+	// keep the pieces inside, merging consecutive ones
+	pieces := []*objects.Line{}
+	ends := [][2]int{}
+	var start *crossing
+	for i := 1; i < len(ts); i++ {
+		if ts[i].t == ts[i-1].t {
+			continue
+		}
+
+		inside := contains(at((ts[i-1].t + ts[i].t) / 2))
+		switch {
+		case inside && start == nil:
+			start = &ts[i-1]
+		case !inside && start != nil:
+			pieces = append(pieces, objects.NewLine(at(start.t), at(ts[i-1].t)))
+			ends = append(ends, [2]int{start.edge, ts[i-1].edge})
+			start = nil
+		}
+	}
+	if start != nil && start.t == 0 {
+		// trivially accept
+		return []*objects.Line{l}, [][2]int{{-1, -1}}
+	}
+	if start != nil {
+		pieces = append(pieces, objects.NewLine(at(start.t), l.B))
+		ends = append(ends, [2]int{start.edge, -1})
+	}
+
+	return pieces, ends
+}
+
+// ClipLine returns the part of the given Line inside the polygon, or nil if
+// none of it is, satisfying the LineClipper interface.
+// If the Line goes in and out of the polygon several times, that is the first
+// of its visible pieces; ClipSegments returns all of them.
+func (pw *PolygonWindow) ClipLine(l *objects.Line) (*objects.Line, error) {
+	return firstPiece(pw.ClipSegments(l))
+}
+
+// ClipShapes clips all the given shapes against the polygon, returning all
+// the visible parts of them in order, just like the ClipShapes function.
+func (pw *PolygonWindow) ClipShapes(shapes []objects.Shape) ([]objects.Shape, error) {
+	return ClipShapes(pw, shapes)
+}
+
+// ClipPolygon returns the part of the given Polygon inside the polygon
+// bounding the PolygonWindow, or nil if none of it is, satisfying the
+// objects.PolygonClipper interface.
+// Convex polygons clip with the Sutherland–Hodgman algorithm, just like
+// Window.ClipPolygon; concave ones with the Weiler–Atherton algorithm of
+// Intersect, all the pieces it yields making up a single Polygon.
+// The given Polygon itself is returned if it lies entirely inside.
+func (pw *PolygonWindow) ClipPolygon(p *objects.Polygon) (*objects.Polygon, error) {
+	if pw.convex != nil {
+		return pw.convex.ClipPolygon(p)
+	}
+
+	// the window being a single ring, the Polygon is entirely inside of it
+	// if all of its outline is
+	inside := true
+	for _, line := range p.Lines() {
+		pieces, err := pw.ClipSegments(line)
+		if err != nil {
+			return nil, err
+		}
+		if len(pieces) != 1 || pieces[0] != line {
+			inside = false
+			break
+		}
+	}
+	if inside {
+		return p, nil
+	}
+
+	rings := [][]*objects.Point{}
+	for _, piece := range Intersect(p, objects.NewPolygon(objects.NonZero, pw.vertices)) {
+		rings = append(rings, piece.Rings...)
+	}
+	if len(rings) == 0 {
+		return nil, nil
+	}
+	return objects.NewPolygon(objects.NonZero, rings...), nil
+}
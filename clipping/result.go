@@ -0,0 +1,329 @@
+package clipping
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"../postscript/objects"
+)
+
+// ClipStatus tells what clipping did to a line.
+type ClipStatus int
+
+const (
+	// Rejected lines lie entirely outside of the clipping region.
+	Rejected ClipStatus = iota
+
+	// Accepted lines lie entirely inside of the clipping region, and are
+	// left as they are.
+	Accepted
+
+	// Clipped lines cross the boundary of the clipping region, and only
+	// part of them is visible.
+	Clipped
+)
+
+// String satisfies fmt.Stringer.
+func (s ClipStatus) String() string {
+	switch s {
+	case Accepted:
+		return "accepted"
+	case Clipped:
+		return "clipped"
+	}
+	return "rejected"
+}
+
+// Edge identifies an edge of a clipping region.
+// The sides of rectangular windows are the ones below; polygonal regions
+// number their edges from 0, the edge starting at each of the vertices they
+// were given going by the index of the vertex.
+type Edge int
+
+// the sides of rectangular windows, and NoEdge, standing for no edge at all
+// where a line does not cross the boundary of the clipping region; all of
+// them negative, for them never to be mistaken for the edges of polygons
+const (
+	LeftEdge Edge = iota - 5
+	RightEdge
+	BottomEdge
+	TopEdge
+	NoEdge
+)
+
+// String satisfies fmt.Stringer.
+func (e Edge) String() string {
+	switch e {
+	case LeftEdge:
+		return "left"
+	case RightEdge:
+		return "right"
+	case BottomEdge:
+		return "bottom"
+	case TopEdge:
+		return "top"
+	case NoEdge:
+		return "none"
+	}
+	return fmt.Sprintf("edge %d", int(e))
+}
+
+// ClipResult describes how a line was clipped.
+type ClipResult struct {
+	Status ClipStatus
+
+	// Original is the line which was clipped, Line the part of it which
+	// is visible, nil if it is rejected
+	Original *objects.Line
+	Line     *objects.Line
+
+	// T0 and T1 are the parameters of the ends of the visible part, the
+	// original line going from its A end at 0 to its B end at 1
+	T0, T1 float64
+
+	// Entry is the edge the line enters the clipping region through, and
+	// Exit the one it leaves it through; NoEdge for the ends of the line
+	// which are inside
+	Entry, Exit Edge
+
+	// Pieces are the results of each of the visible pieces of a line going
+	// in and out of the clipping region several times, in order along the
+	// line, and nil for any other line; Line, T0, T1, Entry and Exit are
+	// then those of the first piece, just as ClipLine returns it
+	Pieces []*ClipResult
+}
+
+// String satisfies fmt.Stringer.
+func (r *ClipResult) String() string {
+	if r.Status == Rejected {
+		return fmt.Sprintf("<%s %s>", r.Status, r.Original)
+	}
+	if len(r.Pieces) > 1 {
+		pieces := make([]string, len(r.Pieces))
+		for i, piece := range r.Pieces {
+			pieces[i] = piece.String()
+		}
+		return fmt.Sprintf("<%s %s in %d pieces: %s>",
+			r.Status, r.Original, len(r.Pieces), strings.Join(pieces, " "))
+	}
+	return fmt.Sprintf("<%s %s t=[%g, %g] entry %s exit %s>",
+		r.Status, r.Line, r.T0, r.T1, r.Entry, r.Exit)
+}
+
+// ResultClipper is implemented by the LineClippers which can tell how they
+// clipped a line, rather than just what is left of it.
+type ResultClipper interface {
+	LineClipper
+
+	// ClipLineResult clips the given Line just like ClipLine, describing
+	// the outcome, along with every one of its visible pieces if there are
+	// several.
+	ClipLineResult(l *objects.Line) (*ClipResult, error)
+}
+
+// polygonEdges returns the edges of the polygon with the given vertices, the
+// edge starting at each vertex in turn.
+func polygonEdges(vertices []*objects.Point) []*objects.Line {
+	edges := make([]*objects.Line, len(vertices))
+	for i, v := range vertices {
+		edges[i] = objects.NewLine(v, vertices[(i+1)%len(vertices)])
+	}
+	return edges
+}
+
+// segmentDistance returns the distance between the Point and the Line
+// segment.
+func segmentDistance(p *objects.Point, l *objects.Line) float64 {
+	dx, dy := l.B.X-l.A.X, l.B.Y-l.A.Y
+	t := 0.0
+	if length := dx*dx + dy*dy; length > 0 {
+		t = math.Max(0, math.Min(1, ((p.X-l.A.X)*dx+(p.Y-l.A.Y)*dy)/length))
+	}
+	return math.Hypot(l.A.X+t*dx-p.X, l.A.Y+t*dy-p.Y)
+}
+
+// nearestEdge returns the one of the given edges the Point lies on, as the
+// nearest one to it; at corners, the first of both edges wins.
+func nearestEdge(p *objects.Point, edges []*objects.Line) Edge {
+	best, bestDist := NoEdge, math.Inf(1)
+	for i, e := range edges {
+		if d := segmentDistance(p, e); d < bestDist {
+			best, bestDist = Edge(i), d
+		}
+	}
+	return best
+}
+
+// lineParam returns the parameter of the Point along the Line, as the
+// projection of the Point onto it.
+func lineParam(l *objects.Line, p *objects.Point) float64 {
+	dx, dy := l.B.X-l.A.X, l.B.Y-l.A.Y
+	length := dx*dx + dy*dy
+	if length == 0 {
+		return 0
+	}
+	return ((p.X-l.A.X)*dx + (p.Y-l.A.Y)*dy) / length
+}
+
+// newClipResult returns the ClipResult of the Line l having been clipped to
+// cl, entering and leaving the clipping region through the given edges.
+func newClipResult(l, cl *objects.Line, entry, exit Edge) *ClipResult {
+	r := &ClipResult{Original: l, Line: cl, Entry: NoEdge, Exit: NoEdge}
+	if cl == nil {
+		r.Status = Rejected
+		return r
+	}
+
+	r.Status = Accepted
+	r.T0, r.T1 = 0, 1
+	if entry != NoEdge {
+		r.Status = Clipped
+		r.T0 = lineParam(l, cl.A)
+		r.Entry = entry
+	}
+	if exit != NoEdge {
+		r.Status = Clipped
+		r.T1 = lineParam(l, cl.B)
+		r.Exit = exit
+	}
+	return r
+}
+
+// ClipLineResult clips the given Line just like ClipLine, with the
+// Cohen–Sutherland algorithm, satisfying the ResultClipper interface.
+// The edges are those the ends of the Line were last moved onto.
+func (w *Window) ClipLineResult(l *objects.Line) (*ClipResult, error) {
+	cl, entry, exit, err := w.clipEdges(l, w.ComputeABRL(l.A), w.ComputeABRL(l.B), NoEdge, NoEdge)
+	if err != nil {
+		return nil, err
+	}
+	return newClipResult(l, cl, entry, exit), nil
+}
+
+// ClipLineResult clips the given Line just like ClipLine, satisfying the
+// ResultClipper interface.
+func (lb *LiangBarsky) ClipLineResult(l *objects.Line) (*ClipResult, error) {
+	cl, entry, exit := lb.clipEdges(l)
+	return newClipResult(l, cl, entry, exit), nil
+}
+
+// ClipLineResult clips the given Line just like ClipLine, satisfying the
+// ResultClipper interface.
+func (nln *NichollLeeNicholl) ClipLineResult(l *objects.Line) (*ClipResult, error) {
+	cl, entry, exit := nln.clipEdges(l)
+	return newClipResult(l, cl, entry, exit), nil
+}
+
+// ClipLineResult clips the given Line just like ClipLine, satisfying the
+// ResultClipper interface.
+// The edges are the sides of rectangles made by NewRectCyrusBeck, and
+// numbered after the vertices NewCyrusBeck was given otherwise.
+func (cb *CyrusBeck) ClipLineResult(l *objects.Line) (*ClipResult, error) {
+	cl, e0, e1 := cb.clipEdges(l)
+	if cl == nil {
+		return newClipResult(l, nil, NoEdge, NoEdge), nil
+	}
+	return newClipResult(l, cl, cb.edge(cl.A, e0), cb.edge(cl.B, e1)), nil
+}
+
+// ClipLineResult clips the given Line just like ClipSegments, satisfying the
+// ResultClipper interface.
+// The edges are numbered after the vertices the PolygonWindow was given.
+// Lines visible in several pieces are described piece by piece in the
+// result's Pieces.
+func (pw *PolygonWindow) ClipLineResult(l *objects.Line) (*ClipResult, error) {
+	if pw.convex != nil {
+		return pw.convex.ClipLineResult(l)
+	}
+
+	pieces, ends := splitLine(l, polygonEdges(pw.vertices), pw.Contains)
+	if len(pieces) == 0 {
+		return newClipResult(l, nil, NoEdge, NoEdge), nil
+	}
+
+	edge := func(i int) Edge {
+		if i < 0 {
+			return NoEdge
+		}
+		return Edge(i)
+	}
+	results := make([]*ClipResult, len(pieces))
+	for i, piece := range pieces {
+		results[i] = newClipResult(l, piece, edge(ends[i][0]), edge(ends[i][1]))
+	}
+	if len(results) == 1 {
+		return results[0], nil
+	}
+
+	r := *results[0]
+	r.Pieces = results
+	return &r, nil
+}
+
+// ClipStats gathers statistics over many ClipResults.
+type ClipStats struct {
+	Accepted, Rejected, Clipped int
+
+	// Entries and Exits count the lines entering and leaving the clipping
+	// region through each of its edges
+	Entries, Exits map[Edge]int
+}
+
+// NewClipStats returns a newly generated, empty ClipStats structure.
+func NewClipStats() *ClipStats {
+	return &ClipStats{Entries: map[Edge]int{}, Exits: map[Edge]int{}}
+}
+
+// Add counts the given ClipResult in, along with the edges crossed by every
+// one of its pieces.
+func (s *ClipStats) Add(r *ClipResult) {
+	switch r.Status {
+	case Accepted:
+		s.Accepted++
+	case Rejected:
+		s.Rejected++
+	case Clipped:
+		s.Clipped++
+	}
+
+	pieces := r.Pieces
+	if pieces == nil {
+		pieces = []*ClipResult{r}
+	}
+	for _, piece := range pieces {
+		if piece.Entry != NoEdge {
+			s.Entries[piece.Entry]++
+		}
+		if piece.Exit != NoEdge {
+			s.Exits[piece.Exit]++
+		}
+	}
+}
+
+// Total returns the number of ClipResults counted in.
+func (s *ClipStats) Total() int {
+	return s.Accepted + s.Rejected + s.Clipped
+}
+
+// String satisfies fmt.Stringer.
+func (s *ClipStats) String() string {
+	// the sides of rectangles first, then the edges of polygons in order
+	counts := func(m map[Edge]int) string {
+		edges := []int{}
+		for e := range m {
+			edges = append(edges, int(e))
+		}
+		sort.Ints(edges)
+
+		res := make([]string, len(edges))
+		for i, e := range edges {
+			res[i] = fmt.Sprintf("%s: %d", Edge(e), m[Edge(e)])
+		}
+		return "{" + strings.Join(res, ", ") + "}"
+	}
+
+	return fmt.Sprintf("<%d lines: %d accepted %d rejected %d clipped, entries %s exits %s>",
+		s.Total(), s.Accepted, s.Rejected, s.Clipped, counts(s.Entries), counts(s.Exits))
+}
@@ -0,0 +1,181 @@
+package clipping
+
+import (
+	"math"
+	"testing"
+
+	"../postscript/objects"
+)
+
+// onSide returns true if the given point lies on the given side of the
+// rectangle
+func onSide(p *objects.Point, e Edge, minx, miny, maxx, maxy float64) bool {
+	near := func(a, b float64) bool {
+		return math.Abs(a-b) < 1e-6
+	}
+
+	switch e {
+	case LeftEdge:
+		return near(p.X, minx)
+	case RightEdge:
+		return near(p.X, maxx)
+	case BottomEdge:
+		return near(p.Y, miny)
+	case TopEdge:
+		return near(p.Y, maxy)
+	}
+	return false
+}
+
+func TestRectClipLineResult(t *testing.T) {
+	lines := append(gridLines(10000, 4), randomLines(10000, 4)...)
+
+	for _, c := range rectClippers(0, 0, testWidth, testHeight) {
+		rc := c.clipper.(ResultClipper)
+		for _, l := range lines {
+			r, err := rc.ClipLineResult(l)
+			if err != nil {
+				t.Fatalf("%s: %s", c.name, err)
+			}
+
+			switch {
+			case r.Status == Rejected:
+				continue
+			case r.Status == Accepted && (r.Entry != NoEdge || r.Exit != NoEdge):
+				t.Errorf("%s: accepted %s enters through %s and leaves through %s", c.name, l, r.Entry, r.Exit)
+			case r.Status == Clipped && r.Entry == NoEdge && r.Exit == NoEdge:
+				t.Errorf("%s: clipped %s crosses no edge", c.name, l)
+			}
+
+			if r.Entry != NoEdge && !onSide(r.Line.A, r.Entry, 0, 0, testWidth, testHeight) {
+				t.Errorf("%s: %s enters at %s, not on the %s edge", c.name, l, r.Line.A, r.Entry)
+			}
+			if r.Exit != NoEdge && !onSide(r.Line.B, r.Exit, 0, 0, testWidth, testHeight) {
+				t.Errorf("%s: %s leaves at %s, not on the %s edge", c.name, l, r.Line.B, r.Exit)
+			}
+		}
+	}
+}
+
+func TestPolygonClipLineResult(t *testing.T) {
+	pt := objects.NewPoint
+	line := func(x1, y1, x2, y2 float64) *objects.Line {
+		return objects.NewLine(pt(x1, y1), pt(x2, y2))
+	}
+
+	// clockwise from the top left corner, with a vertex in the middle of
+	// the bottom edge
+	cw, err := NewPolygonWindow(pt(0, 10), pt(10, 10), pt(10, 0), pt(5, 0), pt(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an L, counter-clockwise from the origin
+	concave, err := NewPolygonWindow(pt(0, 0), pt(10, 0), pt(10, 5), pt(5, 5), pt(5, 10), pt(0, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		window      *PolygonWindow
+		l           *objects.Line
+		entry, exit Edge
+	}{
+		{cw, line(5, 15, 5, 5), 0, NoEdge},
+		{cw, line(5, 5, 15, 5), NoEdge, 1},
+		{cw, line(7, -5, 7, 5), 2, NoEdge},
+		{cw, line(2, -5, 2, 5), 3, NoEdge},
+		{cw, line(-5, 5, 15, 5), 4, 1},
+		{cw, line(2, 2, 8, 8), NoEdge, NoEdge},
+		{concave, line(-5, 2, 8, 2), 5, NoEdge},
+		{concave, line(2, 4, 8, 8), NoEdge, 3},
+		{concave, line(7, 7, 2, 7), 3, NoEdge},
+		{concave, line(8, 2, 8, 7), NoEdge, 2},
+		{concave, line(2, 12, 2, -2), 4, 0},
+	}
+
+	for _, test := range tests {
+		r, err := test.window.ClipLineResult(test.l)
+		if err != nil {
+			t.Errorf("%s: %s", test.l, err)
+			continue
+		}
+		if r.Entry != test.entry || r.Exit != test.exit {
+			t.Errorf("%s enters through %s and leaves through %s, want %s and %s",
+				test.l, r.Entry, r.Exit, test.entry, test.exit)
+		}
+	}
+}
+
+func TestPolygonClipLineResultPieces(t *testing.T) {
+	pt := objects.NewPoint
+
+	// the L of TestPolygonClipLineResult, which the line crosses both arms of
+	concave, err := NewPolygonWindow(pt(0, 0), pt(10, 0), pt(10, 5), pt(5, 5), pt(5, 10), pt(0, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := concave.ClipLineResult(objects.NewLine(pt(2, 12), pt(12, 2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Status != Clipped || len(r.Pieces) != 2 {
+		t.Fatalf("line is %s, want clipped in 2 pieces", r)
+	}
+
+	want := []struct {
+		line        *objects.Line
+		entry, exit Edge
+	}{
+		{objects.NewLine(pt(4, 10), pt(5, 9)), 4, 3},
+		{objects.NewLine(pt(9, 5), pt(10, 4)), 2, 1},
+	}
+	for i, piece := range r.Pieces {
+		if !sameLine(piece.Line, want[i].line) || piece.Entry != want[i].entry || piece.Exit != want[i].exit {
+			t.Errorf("%d'th piece is %s, want %s entering through %s and leaving through %s",
+				i, piece, want[i].line, want[i].entry, want[i].exit)
+		}
+	}
+	if !sameLine(r.Line, r.Pieces[0].Line) || r.Entry != 4 || r.Exit != 3 {
+		t.Errorf("line is %s, want its first piece", r)
+	}
+
+	s := NewClipStats()
+	s.Add(r)
+	if s.Total() != 1 || s.Clipped != 1 {
+		t.Errorf("stats count %s, want a single clipped line", s)
+	}
+	if len(s.Entries) != 2 || s.Entries[2] != 1 || s.Entries[4] != 1 || len(s.Exits) != 2 || s.Exits[1] != 1 || s.Exits[3] != 1 {
+		t.Errorf("stats count %s, want entries through edges 2 and 4, exits through edges 1 and 3", s)
+	}
+}
+
+func TestRectCyrusBeckEdges(t *testing.T) {
+	cb := NewRectCyrusBeck(0, 0, 10, 10)
+
+	r, err := cb.ClipLineResult(objects.NewLine(objects.NewPoint(5, -5), objects.NewPoint(5, 15)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Entry != BottomEdge || r.Exit != TopEdge {
+		t.Errorf("vertical line enters through %s and leaves through %s, want bottom and top", r.Entry, r.Exit)
+	}
+}
+
+func TestEdgeString(t *testing.T) {
+	tests := map[Edge]string{
+		LeftEdge:   "left",
+		RightEdge:  "right",
+		BottomEdge: "bottom",
+		TopEdge:    "top",
+		NoEdge:     "none",
+		0:          "edge 0",
+		3:          "edge 3",
+	}
+	for e, want := range tests {
+		if got := e.String(); got != want {
+			t.Errorf("Edge(%d) is %q, want %q", int(e), got, want)
+		}
+	}
+}
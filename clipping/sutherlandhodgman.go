@@ -0,0 +1,147 @@
+package clipping
+
+import (
+	"../postscript/objects"
+)
+
+// halfPlane is the inner side of one edge of a convex clipping region: all
+// the points p for which n·p >= c, n being the edge's inward normal.
+type halfPlane struct {
+	nx, ny, c float64
+}
+
+// rectHalfPlanes returns the half-planes bounding the given rectangle.
+func rectHalfPlanes(minx, miny, maxx, maxy float64) []halfPlane {
+	return []halfPlane{
+		{1, 0, minx},
+		{-1, 0, -maxx},
+		{0, 1, miny},
+		{0, -1, -maxy},
+	}
+}
+
+// distance returns how far inside of the half-plane the given point lies,
+// scaled by the length of its normal; negative if it lies outside.
+func (h halfPlane) distance(p *objects.Point) float64 {
+	return h.nx*p.X + h.ny*p.Y - h.c
+}
+
+// intersect returns the point where the segment between a and b crosses the
+// edge of the half-plane, the two of them being on either side of it.
+// Points on axis-aligned edges are snapped onto them exactly, so that the
+// edges of clipped polygons line up with the window.
+func (h halfPlane) intersect(a, b *objects.Point) *objects.Point {
+	da, db := h.distance(a), h.distance(b)
+	t := da / (da - db)
+
+	p := objects.NewPoint(a.X+t*(b.X-a.X), a.Y+t*(b.Y-a.Y))
+	switch {
+	case h.ny == 0:
+		p.X = h.c / h.nx
+	case h.nx == 0:
+		p.Y = h.c / h.ny
+	}
+	return p
+}
+
+// clipRing clips the given ring against every one of the half-planes in turn,
+// with the Sutherland–Hodgman algorithm.
+// Each pass walks along the edges of the ring, keeping the vertices inside of
+// the half-plane and adding a vertex wherever an edge crosses its boundary,
+// so that the parts of the ring outside are replaced by stretches of the
+// boundary. Returns nil if nothing of the ring is left.
+func clipRing(ring []*objects.Point, planes []halfPlane) []*objects.Point {
+	out := ring
+	for _, h := range planes {
+		in := out
+		out = nil
+
+		for i, cur := range in {
+			prev := in[(i+len(in)-1)%len(in)]
+			curIn, prevIn := h.distance(cur) >= 0, h.distance(prev) >= 0
+
+			switch {
+			case curIn && !prevIn:
+				out = append(out, h.intersect(prev, cur), cur)
+			case curIn:
+				out = append(out, cur)
+			case prevIn:
+				out = append(out, h.intersect(prev, cur))
+			}
+		}
+
+		if len(out) == 0 {
+			return nil
+		}
+	}
+	return out
+}
+
+// clipPolygon clips every ring of the given Polygon against the convex
+// region bounded by the given half-planes, returning the Polygon itself if
+// it lies entirely inside, or nil if none of it does.
+// Rings are clipped independently of one another, which keeps the holes and
+// the overlaps of the Polygon as they are under either fill rule.
+func clipPolygon(p *objects.Polygon, planes []halfPlane) *objects.Polygon {
+	inside := true
+	for _, ring := range p.Rings {
+		for _, v := range ring {
+			for _, h := range planes {
+				if h.distance(v) < 0 {
+					inside = false
+				}
+			}
+		}
+	}
+	if inside {
+		return p
+	}
+
+	rings := [][]*objects.Point{}
+	for _, ring := range p.Rings {
+		if clipped := clipRing(ring, planes); len(clipped) >= 3 {
+			rings = append(rings, clipped)
+		}
+	}
+	if len(rings) == 0 {
+		return nil
+	}
+	return objects.NewPolygon(p.Rule, rings...)
+}
+
+// ClipPolygon returns the part of the given Polygon inside the Window, or nil
+// if none of it is, satisfying the objects.PolygonClipper interface.
+// Polygons are clipped with the Sutherland–Hodgman algorithm, so that they
+// are closed along the edges of the Window rather than cut open.
+func (w *Window) ClipPolygon(p *objects.Polygon) (*objects.Polygon, error) {
+	return clipPolygon(p, rectHalfPlanes(w.minx, w.miny, w.maxx, w.maxy)), nil
+}
+
+// ClipPolygon returns the part of the given Polygon inside the window, or nil
+// if none of it is, just like Window.ClipPolygon.
+func (lb *LiangBarsky) ClipPolygon(p *objects.Polygon) (*objects.Polygon, error) {
+	return clipPolygon(p, rectHalfPlanes(lb.minx, lb.miny, lb.maxx, lb.maxy)), nil
+}
+
+// ClipPolygon returns the part of the given Polygon inside the rectangle, just
+// like Window.ClipPolygon.
+func (nln *NichollLeeNicholl) ClipPolygon(p *objects.Polygon) (*objects.Polygon, error) {
+	return clipPolygon(p, rectHalfPlanes(nln.minx, nln.miny, nln.maxx, nln.maxy)), nil
+}
+
+// halfPlanes returns the half-planes bounding the convex polygon.
+func (cb *CyrusBeck) halfPlanes() []halfPlane {
+	planes := make([]halfPlane, len(cb.vertices))
+	for i, v := range cb.vertices {
+		n := cb.normals[i]
+		planes[i] = halfPlane{n.X, n.Y, n.X*v.X + n.Y*v.Y}
+	}
+	return planes
+}
+
+// ClipPolygon returns the part of the given Polygon inside the convex
+// polygon, or nil if none of it is, with the Sutherland–Hodgman algorithm
+// just like Window.ClipPolygon.
+func (cb *CyrusBeck) ClipPolygon(p *objects.Polygon) (*objects.Polygon, error) {
+	return clipPolygon(p, cb.halfPlanes()), nil
+}
@@ -0,0 +1,137 @@
+package clipping
+
+import (
+	"fmt"
+	"math"
+
+	"../postscript/objects"
+)
+
+// AspectMode determines how a Viewport maps a window whose aspect ratio is not
+// its own.
+type AspectMode int
+
+const (
+	// Stretch scales the window differently along each axis, for it to fill
+	// the whole of the viewport.
+	Stretch AspectMode = iota
+
+	// Preserve scales the window alike along both axes, as much as fits in
+	// the viewport, and centers it there.
+	Preserve
+)
+
+// String satisfies fmt.Stringer.
+func (m AspectMode) String() string {
+	if m == Preserve {
+		return "preserve"
+	}
+	return "stretch"
+}
+
+// Viewport maps the clipping window, in world coordinates, to a rectangle of
+// the device, such as a part of an XPM bitmap, so that whatever is visible
+// through the window is scaled to fill the rectangle.
+// Its mapping is the usual window-to-viewport transformation, a scaling
+// followed by a translation, which satisfies the objects.Transformer
+// interface.
+type Viewport struct {
+	window                 LineClipper
+	minx, miny, maxx, maxy float64
+	mode                   AspectMode
+
+	// the mapping, from (x, y) to (sx*x + tx, sy*y + ty)
+	sx, sy, tx, ty float64
+}
+
+// NewViewport generates a new Viewport instance, mapping the bounds of the
+// given window (usually a Window) to the device rectangle with the given
+// corners, according to the given AspectMode.
+// Returns an error if either the window or the rectangle has no area.
+func NewViewport(window LineClipper, minx, miny, maxx, maxy float64, mode AspectMode) (*Viewport, error) {
+	wb := window.Bounds()
+	ww, wh := wb.URX-wb.LLX, wb.URY-wb.LLY
+	vw, vh := maxx-minx, maxy-miny
+	if ww <= 0 || wh <= 0 {
+		return nil, fmt.Errorf("Window %s has no area", wb)
+	}
+	if vw <= 0 || vh <= 0 {
+		return nil, fmt.Errorf("Viewport %s has no area", objects.NewRect(minx, miny, maxx, maxy))
+	}
+
+	v := &Viewport{
+		window: window,
+		minx:   minx,
+		miny:   miny,
+		maxx:   maxx,
+		maxy:   maxy,
+		mode:   mode,
+		sx:     vw / ww,
+		sy:     vh / wh,
+	}
+
+	// center the window's image along the axis it does not fill
+	ox, oy := minx, miny
+	if mode == Preserve {
+		s := math.Min(v.sx, v.sy)
+		v.sx, v.sy = s, s
+		ox += (vw - s*ww) / 2
+		oy += (vh - s*wh) / 2
+	}
+	v.tx = ox - v.sx*wb.LLX
+	v.ty = oy - v.sy*wb.LLY
+
+	return v, nil
+}
+
+// Window returns the window mapped by the Viewport.
+func (v *Viewport) Window() LineClipper {
+	return v.window
+}
+
+// Mode returns the AspectMode of the Viewport.
+func (v *Viewport) Mode() AspectMode {
+	return v.mode
+}
+
+// Bounds returns the device rectangle of the Viewport.
+func (v *Viewport) Bounds() *objects.Rect {
+	return objects.NewRect(v.minx, v.miny, v.maxx, v.maxy)
+}
+
+// Scale returns the factors by which the Viewport scales the window
+// horizontally and vertically; both are the same if it preserves the aspect
+// ratio.
+func (v *Viewport) Scale() (sx, sy float64) {
+	return v.sx, v.sy
+}
+
+// TransformPoint maps the given Point from world to device coordinates,
+// satisfying the objects.Transformer interface.
+func (v *Viewport) TransformPoint(p *objects.Point) *objects.Point {
+	return objects.NewPoint(v.sx*p.X+v.tx, v.sy*p.Y+v.ty)
+}
+
+// WorldPoint maps the given Point from device back to world coordinates,
+// undoing TransformPoint.
+func (v *Viewport) WorldPoint(p *objects.Point) *objects.Point {
+	return objects.NewPoint((p.X-v.tx)/v.sx, (p.Y-v.ty)/v.sy)
+}
+
+// MapShapes maps all the given shapes from world to device coordinates, in
+// order.
+func (v *Viewport) MapShapes(shapes []objects.Shape) []objects.Shape {
+	mapped := make([]objects.Shape, len(shapes))
+	for i, shape := range shapes {
+		mapped[i] = shape.Transform(v)
+	}
+	return mapped
+}
+
+// ClipShapes clips all the given shapes against the window, just like the
+// ClipShapes function, and maps the visible parts of them to device
+// coordinates.
+func (v *Viewport) ClipShapes(shapes []objects.Shape) ([]objects.Shape, error) {
+	clipped, err := ClipShapes(v.window, shapes)
+	return v.MapShapes(clipped), err
+}
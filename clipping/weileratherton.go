@@ -0,0 +1,399 @@
+package clipping
+
+import (
+	"math"
+	"sort"
+
+	"../postscript/objects"
+)
+
+// the tolerance within which points are considered the same, as a fraction
+// of the size of the polygons, and within which intersections are snapped
+// onto the ends of the edges they lie on, as a fraction of the edges' lengths
+const snapTolerance = 1e-9
+
+// vertex is the key of a point in the maps of the boolean operations, for
+// points shared by several edges to be told apart by their coordinates.
+type vertex struct {
+	x, y float64
+}
+
+// key returns the vertex of the given point.
+func key(p *objects.Point) vertex {
+	return vertex{p.X, p.Y}
+}
+
+// fragment is a piece of an edge of either polygon, running between two
+// consecutive points where the edge meets other ones.
+type fragment struct {
+	a, b *objects.Point
+}
+
+// split is a point where an edge is to be split, t being how far along the
+// edge it lies.
+type split struct {
+	t float64
+	p *objects.Point
+}
+
+// splitEdges returns the fragments of all the edges of the given rings, each
+// edge being split at every point where it crosses or touches any other one,
+// intersections within the given tolerance of an end of either edge being
+// that very end.
+// Points shared by several edges are the very same Point values on all of
+// them, so that the fragments meet exactly.
+func splitEdges(rings [][]*objects.Point, tol float64) []fragment {
+	edges := []*objects.Line{}
+	for _, ring := range rings {
+		for i, a := range ring {
+			b := ring[(i+1)%len(ring)]
+			if a.X != b.X || a.Y != b.Y {
+				edges = append(edges, objects.NewLine(a, b))
+			}
+		}
+	}
+
+	splits := make([][]split, len(edges))
+	for i, e := range edges {
+		splits[i] = []split{{0, e.A}, {1, e.B}}
+	}
+
+	// param returns how far along the edge e the point p (known to lie on
+	// its supporting line) is
+	param := func(e *objects.Line, p *objects.Point) float64 {
+		dx, dy := e.B.X-e.A.X, e.B.Y-e.A.Y
+		return ((p.X-e.A.X)*dx + (p.Y-e.A.Y)*dy) / (dx*dx + dy*dy)
+	}
+
+	for i, e := range edges {
+		for j := i + 1; j < len(edges); j++ {
+			f := edges[j]
+			ex, ey := e.B.X-e.A.X, e.B.Y-e.A.Y
+			fx, fy := f.B.X-f.A.X, f.B.Y-f.A.Y
+
+			den := ex*fy - ey*fx
+			if den == 0 {
+				// overlapping collinear edges are split at each other's
+				// end points
+				if cross(e.A, e.B, f.A) != 0 {
+					continue
+				}
+				for _, p := range []*objects.Point{f.A, f.B} {
+					if t := param(e, p); t > 0 && t < 1 {
+						splits[i] = append(splits[i], split{t, p})
+					}
+				}
+				for _, p := range []*objects.Point{e.A, e.B} {
+					if u := param(f, p); u > 0 && u < 1 {
+						splits[j] = append(splits[j], split{u, p})
+					}
+				}
+				continue
+			}
+
+			t := ((f.A.X-e.A.X)*fy - (f.A.Y-e.A.Y)*fx) / den
+			u := ((f.A.X-e.A.X)*ey - (f.A.Y-e.A.Y)*ex) / den
+			if t < -snapTolerance || t > 1+snapTolerance || u < -snapTolerance || u > 1+snapTolerance {
+				continue
+			}
+
+			// intersections at the end of either edge are that very end
+			var p *objects.Point
+			switch {
+			case math.Abs(t) <= snapTolerance:
+				p, t = e.A, 0
+			case math.Abs(t-1) <= snapTolerance:
+				p, t = e.B, 1
+			case math.Abs(u) <= snapTolerance:
+				p = f.A
+			case math.Abs(u-1) <= snapTolerance:
+				p = f.B
+			default:
+				p = objects.NewPoint(e.A.X+t*ex, e.A.Y+t*ey)
+				for _, end := range []*objects.Point{e.A, e.B, f.A, f.B} {
+					if math.Abs(p.X-end.X) <= tol && math.Abs(p.Y-end.Y) <= tol {
+						p = end
+					}
+				}
+			}
+			switch {
+			case p == e.A:
+				t = 0
+			case p == e.B:
+				t = 1
+			}
+			switch {
+			case p == f.A || math.Abs(u) <= snapTolerance:
+				u = 0
+			case p == f.B || math.Abs(u-1) <= snapTolerance:
+				u = 1
+			}
+
+			splits[i] = append(splits[i], split{t, p})
+			splits[j] = append(splits[j], split{u, p})
+		}
+	}
+
+	frags := []fragment{}
+	for _, s := range splits {
+		sort.SliceStable(s, func(i, j int) bool { return s[i].t < s[j].t })
+		prev := s[0].p
+		for _, sp := range s[1:] {
+			if sp.p.X != prev.X || sp.p.Y != prev.Y {
+				frags = append(frags, fragment{prev, sp.p})
+				prev = sp.p
+			}
+		}
+	}
+	return frags
+}
+
+// snapVertices returns copies of both Polygons in which all the vertices
+// lying within the given tolerance of one another are the very same Point,
+// for the rings to meet exactly wherever they are meant to, such as at both
+// ends of a closed arc.
+func snapVertices(a, b *objects.Polygon, tol float64) (*objects.Polygon, *objects.Polygon) {
+	points := []*objects.Point{}
+	snap := func(p *objects.Point) *objects.Point {
+		for _, q := range points {
+			if math.Abs(p.X-q.X) <= tol && math.Abs(p.Y-q.Y) <= tol {
+				return q
+			}
+		}
+		points = append(points, p)
+		return p
+	}
+
+	snapped := func(p *objects.Polygon) *objects.Polygon {
+		rings := make([][]*objects.Point, len(p.Rings))
+		for i, ring := range p.Rings {
+			rings[i] = make([]*objects.Point, len(ring))
+			for j, v := range ring {
+				rings[i][j] = snap(v)
+			}
+		}
+		return objects.NewPolygon(p.Rule, rings...)
+	}
+	return snapped(a), snapped(b)
+}
+
+// combine returns the area of the points p for which keep(a contains p,
+// b contains p) holds, each of the two Polygons going by its own fill rule.
+// This is Weiler–Atherton clipping, generalized to any boolean operation: the
+// outlines of both Polygons are split wherever they meet, the fragments with
+// the resulting area on one side and not on the other are kept, turned so as
+// to have it on their left, and traced back into rings. Outer rings thus go
+// counter-clockwise and holes clockwise, and each outer ring is returned as
+// a Polygon of its own along with the holes it contains.
+func combine(a, b *objects.Polygon, keep func(inA, inB bool) bool) []*objects.Polygon {
+	bounds := a.Bounds().Union(b.Bounds())
+	if bounds == nil {
+		return nil
+	}
+	size := math.Max(bounds.URX-bounds.LLX, bounds.URY-bounds.LLY)
+	tol := snapTolerance * math.Max(size, 1)
+
+	a, b = snapVertices(a, b, tol)
+	rings := append(append([][]*objects.Point{}, a.Rings...), b.Rings...)
+
+	in := func(p *objects.Point) bool {
+		return keep(a.Contains(p), b.Contains(p))
+	}
+
+	// the boundary of the result, with every fragment going from each of
+	// its ends to the fragments going on from there
+	outgoing := map[vertex][]fragment{}
+	seen := map[[2]vertex]bool{}
+	for _, f := range splitEdges(rings, tol) {
+		// probe both sides of the fragment, just off its middle
+		length := math.Hypot(f.b.X-f.a.X, f.b.Y-f.a.Y)
+		eps := math.Min(length/4, 1000*tol) / length
+		dx, dy := (f.b.X-f.a.X)*eps, (f.b.Y-f.a.Y)*eps
+
+		mx, my := (f.a.X+f.b.X)/2, (f.a.Y+f.b.Y)/2
+		left := in(objects.NewPoint(mx-dy, my+dx))
+		right := in(objects.NewPoint(mx+dy, my-dx))
+		if left == right {
+			continue
+		}
+		if right {
+			f.a, f.b = f.b, f.a
+		}
+
+		// overlapping edges of both Polygons yield the same fragment twice
+		k := [2]vertex{key(f.a), key(f.b)}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		outgoing[k[0]] = append(outgoing[k[0]], f)
+	}
+
+	var outers, holes [][]*objects.Point
+	for _, ring := range traceRings(outgoing) {
+		switch area := ringArea(ring); {
+		case area > 0:
+			outers = append(outers, ring)
+		case area < 0:
+			holes = append(holes, ring)
+		}
+	}
+	return groupHoles(outers, holes)
+}
+
+// traceRings chains the given fragments back into closed rings, leaving out
+// any chain which cannot be closed.
+// Where several fragments go on from the same point, the one turning the
+// most to the left is followed, which keeps the area on the left of the ring
+// in one piece; areas touching at a single point get a ring each.
+func traceRings(outgoing map[vertex][]fragment) [][]*objects.Point {
+	// go through the starting points in a fixed order, for the results not
+	// to depend on the order of the map
+	starts := []vertex{}
+	for v := range outgoing {
+		starts = append(starts, v)
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		if starts[i].x != starts[j].x {
+			return starts[i].x < starts[j].x
+		}
+		return starts[i].y < starts[j].y
+	})
+
+	// next removes and returns the fragment going on from the end of f
+	next := func(f fragment) (fragment, bool) {
+		frags := outgoing[key(f.b)]
+		if len(frags) == 0 {
+			return fragment{}, false
+		}
+
+		dx, dy := f.b.X-f.a.X, f.b.Y-f.a.Y
+		best, bestTurn := 0, math.Inf(-1)
+		for i, g := range frags {
+			gx, gy := g.b.X-g.a.X, g.b.Y-g.a.Y
+			if turn := math.Atan2(dx*gy-dy*gx, dx*gx+dy*gy); turn > bestTurn {
+				best, bestTurn = i, turn
+			}
+		}
+
+		g := frags[best]
+		outgoing[key(f.b)] = append(frags[:best:best], frags[best+1:]...)
+		return g, true
+	}
+
+	rings := [][]*objects.Point{}
+	for _, start := range starts {
+		for len(outgoing[start]) > 0 {
+			f := outgoing[start][0]
+			outgoing[start] = outgoing[start][1:]
+
+			ring := []*objects.Point{f.a}
+			closed := false
+			for {
+				if key(f.b) == start {
+					closed = true
+					break
+				}
+				ring = append(ring, f.b)
+
+				var ok bool
+				if f, ok = next(f); !ok {
+					break
+				}
+			}
+
+			if ring = simplifyRing(ring); closed && len(ring) >= 3 {
+				rings = append(rings, ring)
+			}
+		}
+	}
+	return rings
+}
+
+// simplifyRing returns the given ring without the vertices lying in between
+// their neighbours, which splitting edges leaves behind.
+func simplifyRing(ring []*objects.Point) []*objects.Point {
+	for changed := true; changed && len(ring) >= 3; {
+		changed = false
+		res := []*objects.Point{}
+		for i, v := range ring {
+			prev := ring[(i+len(ring)-1)%len(ring)]
+			next := ring[(i+1)%len(ring)]
+
+			d1 := math.Hypot(v.X-prev.X, v.Y-prev.Y)
+			d2 := math.Hypot(next.X-v.X, next.Y-v.Y)
+			straight := math.Abs(cross(prev, v, next)) <= snapTolerance*d1*d2 &&
+				(v.X-prev.X)*(next.X-v.X)+(v.Y-prev.Y)*(next.Y-v.Y) > 0
+			if straight {
+				changed = true
+				continue
+			}
+			res = append(res, v)
+		}
+		ring = res
+	}
+	return ring
+}
+
+// ringArea returns twice the signed area of the given ring, positive if it
+// goes counter-clockwise.
+func ringArea(ring []*objects.Point) float64 {
+	area := 0.0
+	for i, p := range ring {
+		q := ring[(i+1)%len(ring)]
+		area += p.X*q.Y - q.X*p.Y
+	}
+	return area
+}
+
+// groupHoles returns a Polygon for every one of the given outer rings, along
+// with the holes which lie within it and within no smaller outer ring.
+func groupHoles(outers, holes [][]*objects.Point) []*objects.Polygon {
+	polygons := make([]*objects.Polygon, len(outers))
+	for i, outer := range outers {
+		polygons[i] = objects.NewPolygon(objects.NonZero, outer)
+	}
+
+	for _, hole := range holes {
+		// a point just inside of the hole, off the middle of its first edge
+		a, b := hole[0], hole[1]
+		eps := 1e-7
+		p := objects.NewPoint((a.X+b.X)/2+(b.Y-a.Y)*eps, (a.Y+b.Y)/2-(b.X-a.X)*eps)
+
+		best, bestArea := -1, math.Inf(1)
+		for i, outer := range outers {
+			area := ringArea(outer)
+			if area < bestArea && objects.NewPolygon(objects.NonZero, outer).Contains(p) {
+				best, bestArea = i, area
+			}
+		}
+		if best >= 0 {
+			polygons[best].Rings = append(polygons[best].Rings, hole)
+		}
+	}
+	return polygons
+}
+
+// Intersect returns the area common to both Polygons, as separate Polygons
+// with their holes, each of the given ones going by its own fill rule.
+// The Polygons may be concave, cross themselves and have holes.
+func Intersect(a, b *objects.Polygon) []*objects.Polygon {
+	return combine(a, b, func(inA, inB bool) bool { return inA && inB })
+}
+
+// Union returns the area covered by either Polygon, just like Intersect.
+func Union(a, b *objects.Polygon) []*objects.Polygon {
+	return combine(a, b, func(inA, inB bool) bool { return inA || inB })
+}
+
+// Difference returns the area covered by the first Polygon but not by the
+// second one, just like Intersect.
+func Difference(a, b *objects.Polygon) []*objects.Polygon {
+	return combine(a, b, func(inA, inB bool) bool { return inA && !inB })
+}
+
+// Xor returns the area covered by exactly one of the Polygons, just like
+// Intersect.
+func Xor(a, b *objects.Polygon) []*objects.Polygon {
+	return combine(a, b, func(inA, inB bool) bool { return inA != inB })
+}
@@ -9,24 +9,42 @@ import (
 // Window is the base type for a viewport object.
 // The vertical minimums are modeled after the right-handed cartesian.
 type Window struct {
-	minx, miny, maxx, maxy int
+	minx, miny, maxx, maxy float64
 	a, b, r, l             *objects.Line
 }
 
 // NewWindow generates a new Window instance.
-func NewWindow(minx, miny, maxx, maxy int) *Window {
+// Windows of no width or height are fine, clipping lines down to the segment
+// or the point they are reduced to.
+func NewWindow(minx, miny, maxx, maxy float64) *Window {
+	// the edges are only used for the lines through them, which those of a
+	// window of no width or height would not tell
+	right, top := maxx, maxy
+	if right == minx {
+		right = minx + 1
+	}
+	if top == miny {
+		top = miny + 1
+	}
+
 	return &Window{
 		minx: minx,
 		miny: miny,
 		maxx: maxx,
 		maxy: maxy,
-		a:    objects.NewLine(objects.NewPoint(minx, maxy), objects.NewPoint(maxx, maxy)),
-		b:    objects.NewLine(objects.NewPoint(minx, miny), objects.NewPoint(maxx, miny)),
-		r:    objects.NewLine(objects.NewPoint(maxx, miny), objects.NewPoint(maxx, maxy)),
-		l:    objects.NewLine(objects.NewPoint(minx, miny), objects.NewPoint(minx, maxy)),
+		a:    objects.NewLine(objects.NewPoint(minx, maxy), objects.NewPoint(right, maxy)),
+		b:    objects.NewLine(objects.NewPoint(minx, miny), objects.NewPoint(right, miny)),
+		r:    objects.NewLine(objects.NewPoint(maxx, miny), objects.NewPoint(maxx, top)),
+		l:    objects.NewLine(objects.NewPoint(minx, miny), objects.NewPoint(minx, top)),
 	}
 }
 
+// Bounds returns the rectangle of the Window, satisfying the
+// objects.Clipper interface.
+func (w *Window) Bounds() *objects.Rect {
+	return objects.NewRect(w.minx, w.miny, w.maxx, w.maxy)
+}
+
 // ComputeABRL returns the ABRL code of a given point.
 func (w *Window) ComputeABRL(p *objects.Point) int {
 	var abrl int = 0
@@ -69,10 +87,10 @@ func (w *Window) LineClips(l *objects.Line) bool {
 }
 
 // getLineEquation returns the parameters of the equation of the given Line.
-func getLineEquation(l *objects.Line) (a, b, c int) {
-	a = int(l.B.Y) - int(l.A.Y)
-	b = int(l.A.X) - int(l.B.X)
-	c = a*int(l.A.X) + b*int(l.A.Y)
+func getLineEquation(l *objects.Line) (a, b, c float64) {
+	a = l.B.Y - l.A.Y
+	b = l.A.X - l.B.X
+	c = a*l.A.X + b*l.A.Y
 	return
 }
 
@@ -96,52 +114,100 @@ func computeIntersection(L1, L2 *objects.Line) (p *objects.Point) {
 
 // filterIntersection is a helper method which filters which is the
 // intersection of a given line with the immediate edge.
+// The intersection is snapped onto the edge so that rounding errors can never
+// leave it outside of the window.
 func (w *Window) filterIntersection(l *objects.Line, abrl int) *objects.Point {
+	var p *objects.Point
 	switch {
 	case abrl&8 != 0:
-		return computeIntersection(w.a, l)
+		if p = computeIntersection(w.a, l); p != nil {
+			p.Y = w.maxy
+		}
 	case abrl&4 != 0:
-		return computeIntersection(w.b, l)
+		if p = computeIntersection(w.b, l); p != nil {
+			p.Y = w.miny
+		}
 	case abrl&2 != 0:
-		return computeIntersection(w.r, l)
+		if p = computeIntersection(w.r, l); p != nil {
+			p.X = w.maxx
+		}
 	case abrl&1 != 0:
-		return computeIntersection(w.l, l)
+		if p = computeIntersection(w.l, l); p != nil {
+			p.X = w.minx
+		}
 	}
 
-	return nil
+	return p
 }
 
 // ClipLine is a function which takes a Line object and returns
 // a new Line which is the clipped version of the given Line.
+// It implements the recursive Cohen–Sutherland algorithm, satisfying the
+// LineClipper interface.
 func (w *Window) ClipLine(l *objects.Line) (*objects.Line, error) {
-	abrl1 := w.ComputeABRL(l.A)
-	abrl2 := w.ComputeABRL(l.B)
+	return w.clipLine(l, w.ComputeABRL(l.A), w.ComputeABRL(l.B))
+}
 
+// ClipSegments returns the part of the given Line inside the Window, if any,
+// as the single piece it is, satisfying the LineClipper interface.
+func (w *Window) ClipSegments(l *objects.Line) ([]*objects.Line, error) {
+	return onePiece(w.ClipLine(l))
+}
+
+// clipLine clips the given Line just like ClipLine, given the ABRL codes of
+// its end points.
+func (w *Window) clipLine(l *objects.Line, abrl1, abrl2 int) (*objects.Line, error) {
+	cl, _, _, err := w.clipEdges(l, abrl1, abrl2, NoEdge, NoEdge)
+	return cl, err
+}
+
+// abrlEdge returns the edge filterIntersection intersects a line with, for
+// an end of it with the given ABRL code.
+func abrlEdge(abrl int) Edge {
+	switch {
+	case abrl&8 != 0:
+		return TopEdge
+	case abrl&4 != 0:
+		return BottomEdge
+	case abrl&2 != 0:
+		return RightEdge
+	}
+	return LeftEdge
+}
+
+// clipEdges clips the given Line just like clipLine, along with the edges it
+// enters and leaves the Window through, given the ones its ends were last
+// moved onto.
+func (w *Window) clipEdges(l *objects.Line, abrl1, abrl2 int, entry, exit Edge) (*objects.Line, Edge, Edge, error) {
 	if abrl1|abrl2 == 0 {
 		// trivially accept
-		return l, nil
+		return l, entry, exit, nil
 	}
 	if abrl1&abrl2 != 0 {
-		return nil, nil
+		return nil, NoEdge, NoEdge, nil
 	}
 
 	// We try the first point of the line.
 	if abrl1 != 0 {
 		inter := w.filterIntersection(l, abrl1)
 		if inter == nil {
-			return nil, fmt.Errorf("Failed to intersect line: (%d, %d) - (%d, %d)",
-				l.A.X, l.A.Y, l.B.X, l.B.Y)
+			return nil, NoEdge, NoEdge, fmt.Errorf("Failed to intersect line: %s", l)
 		}
-		return w.ClipLine(objects.NewLine(inter, l.B))
+		return w.clipEdges(objects.NewLine(inter, l.B), w.ComputeABRL(inter), abrl2, abrlEdge(abrl1), exit)
 	} else if abrl2 != 0 {
 		inter := w.filterIntersection(l, abrl2)
 		if inter == nil {
-			return nil, fmt.Errorf("Failed to intersect line: (%d, %d) - (%d, %d)",
-				l.A.X, l.A.Y, l.B.X, l.B.Y)
+			return nil, NoEdge, NoEdge, fmt.Errorf("Failed to intersect line: %s", l)
 		}
-		return w.ClipLine(objects.NewLine(l.A, inter))
+		return w.clipEdges(objects.NewLine(l.A, inter), abrl1, w.ComputeABRL(inter), entry, abrlEdge(abrl2))
 	}
 
 	// if no clipping is required any more:
-	return l, nil
+	return l, entry, exit, nil
+}
+
+// ClipShapes clips all the given shapes against the Window, returning all the
+// visible parts of them in order, just like the ClipShapes function.
+func (w *Window) ClipShapes(shapes []objects.Shape) ([]objects.Shape, error) {
+	return ClipShapes(w, shapes)
 }
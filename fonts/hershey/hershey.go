@@ -0,0 +1,130 @@
+// Package hershey provides the single-stroke vector fonts designed by
+// Dr. A. V. Hershey, which lay out text as plain line segments so that it can
+// be transformed, clipped and drawn like any other geometry.
+package hershey
+
+import (
+	"../../postscript/objects"
+)
+
+// UnitsPerEm is the number of font units making up the font size; the glyphs
+// are laid out on a grid spanning 32 units from descender to ascender
+const UnitsPerEm = 32
+
+// vertex is a single point of a glyph, in font units
+type vertex struct {
+	x, y int
+}
+
+// Glyph is the outline of a single character as a set of pen strokes
+type Glyph struct {
+	// Advance is the distance (in font units) from the glyph's origin to
+	// the origin of the next glyph
+	Advance int
+
+	// strokes are the polylines drawn without lifting the pen
+	strokes [][]vertex
+}
+
+// newGlyph decodes a glyph from the advance-and-vertices representation of
+// the font tables, where a vertex of (-1, -1) lifts the pen
+func newGlyph(data []int) *Glyph {
+	g := &Glyph{Advance: data[0], strokes: [][]vertex{}}
+
+	stroke := []vertex{}
+	for i := 1; i+1 < len(data); i += 2 {
+		if data[i] == -1 && data[i+1] == -1 {
+			g.strokes = append(g.strokes, stroke)
+			stroke = []vertex{}
+			continue
+		}
+		stroke = append(stroke, vertex{data[i], data[i+1]})
+	}
+	if len(stroke) > 0 {
+		g.strokes = append(g.strokes, stroke)
+	}
+
+	return g
+}
+
+// Font is a Hershey font, mapping characters to their glyphs
+type Font struct {
+	Name string
+
+	glyphs map[rune]*Glyph
+
+	// the glyph standing in for all the characters the font lacks
+	missing *Glyph
+}
+
+// Simplex is the Roman Simplex font, the simplest of the Hershey fonts
+var Simplex = newASCIIFont("Hershey-Simplex", simplexData[:])
+
+// newASCIIFont returns a new Font from the table of the glyphs of the
+// printable ASCII range, starting with the space character
+func newASCIIFont(name string, data [][]int) *Font {
+	f := &Font{Name: name, glyphs: make(map[rune]*Glyph)}
+
+	for i, d := range data {
+		f.glyphs[rune(' '+i)] = newGlyph(d)
+	}
+	f.missing = f.glyphs['?']
+
+	return f
+}
+
+// Glyph returns the glyph of the given character, or that of a question
+// mark if the font has none
+func (f *Font) Glyph(r rune) *Glyph {
+	if g, ok := f.glyphs[r]; ok {
+		return g
+	}
+	return f.missing
+}
+
+// StringWidth returns the width of the given text when set in the given size
+func (f *Font) StringWidth(text string, size float64) float64 {
+	units := 0
+	for _, r := range text {
+		units = units + f.Glyph(r).Advance
+	}
+
+	return float64(units) * size / UnitsPerEm
+}
+
+// Layout sets the given text in the given size, with the origin of its first
+// glyph at (x, y), and returns the resulting strokes as Lines
+func (f *Font) Layout(text string, x, y, size float64) []*objects.Line {
+	lines := []*objects.Line{}
+	scale := size / UnitsPerEm
+
+	for _, r := range text {
+		g := f.Glyph(r)
+
+		for _, stroke := range g.strokes {
+			for i := 1; i < len(stroke); i++ {
+				a, b := stroke[i-1], stroke[i]
+				lines = append(lines, objects.NewLine(
+					objects.NewPoint(x+float64(a.x)*scale, y+float64(a.y)*scale),
+					objects.NewPoint(x+float64(b.x)*scale, y+float64(b.y)*scale),
+				))
+			}
+		}
+
+		x = x + float64(g.Advance)*scale
+	}
+
+	return lines
+}
+
+// fonts maps the names of all the embedded fonts to the fonts themselves
+var fonts = map[string]*Font{
+	Simplex.Name: Simplex,
+}
+
+// Lookup returns the embedded font with the given name
+// ok is false if there is no such font
+func Lookup(name string) (f *Font, ok bool) {
+	f, ok = fonts[name]
+	return
+}
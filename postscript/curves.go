@@ -0,0 +1,250 @@
+package postscript
+
+import (
+	"fmt"  // for fmt.Errorf
+	"math" // for trigonometry and math.Hypot
+)
+
+// the limits postscript imposes on the flatness tolerance
+const (
+	minFlatness = 0.2
+	maxFlatness = 100
+)
+
+// maxSubdivisions caps the recursion depth of the curve flattening so that
+// degenerate curves can never recurse indefinitely
+const maxSubdivisions = 16
+
+// clampFlatness brings the given flatness within postscript's limits
+func clampFlatness(f float64) float64 {
+	return math.Max(minFlatness, math.Min(maxFlatness, f))
+}
+
+// distanceToChord returns the distance between p and the line through a and b
+// If a and b coincide, it is simply the distance between p and a
+func distanceToChord(p, a, b point) float64 {
+	dx := b.x - a.x
+	dy := b.y - a.y
+
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return math.Hypot(p.x-a.x, p.y-a.y)
+	}
+
+	return math.Abs(dx*(a.y-p.y)-dy*(a.x-p.x)) / length
+}
+
+// midpoint returns the point halfway between a and b
+func midpoint(a, b point) point {
+	return point{(a.x + b.x) / 2, (a.y + b.y) / 2}
+}
+
+// flattenBezier approximates the cubic Bézier curve with the given control
+// points by a sequence of straight segments, appending all their endpoints
+// (except for the starting p0) to dst
+// The curve is adaptively split in halves using de Casteljau's algorithm
+// until both inner control points lie within flat of the chord
+func flattenBezier(dst []point, p0, p1, p2, p3 point, flat float64, depth int) []point {
+	if depth >= maxSubdivisions ||
+		(distanceToChord(p1, p0, p3) <= flat && distanceToChord(p2, p0, p3) <= flat) {
+		return append(dst, p3)
+	}
+
+	// de Casteljau split at t = 1/2
+	p01 := midpoint(p0, p1)
+	p12 := midpoint(p1, p2)
+	p23 := midpoint(p2, p3)
+	p012 := midpoint(p01, p12)
+	p123 := midpoint(p12, p23)
+	mid := midpoint(p012, p123)
+
+	dst = flattenBezier(dst, p0, p01, p012, mid, flat, depth+1)
+	return flattenBezier(dst, mid, p123, p23, p3, flat, depth+1)
+}
+
+// arcPoint returns the point at the given angle (in radians) on the circle
+func arcPoint(c point, r, angle float64) point {
+	return point{c.x + r*math.Cos(angle), c.y + r*math.Sin(angle)}
+}
+
+// flattenArc approximates the circular arc centered in c with radius r going
+// from angle a1 to angle a2 (in radians, counter-clockwise if a2 > a1 and
+// clockwise otherwise), appending the resulting points (except for the
+// arc's starting point) to dst
+// As in most postscript implementations, the arc is first converted into
+// Bézier curves spanning at most 90 degrees each, which are then flattened
+// Sweeps of more than a whole turn are capped to one
+func flattenArc(dst []point, c point, r, a1, a2, flat float64) []point {
+	// going around more than once draws nothing more
+	sweep := math.Max(-2*math.Pi, math.Min(2*math.Pi, a2-a1))
+	n := int(math.Ceil(math.Abs(sweep) / (math.Pi / 2)))
+	if n == 0 {
+		return dst
+	}
+
+	step := sweep / float64(n)
+
+	// the distance from each endpoint to its adjacent control point,
+	// for the control points' tangents to make a near-perfect arc
+	k := 4.0 / 3.0 * math.Tan(step/4) * r
+
+	for i := 0; i < n; i++ {
+		start := a1 + float64(i)*step
+		end := start + step
+
+		p0 := arcPoint(c, r, start)
+		p3 := arcPoint(c, r, end)
+		p1 := point{p0.x - k*math.Sin(start), p0.y + k*math.Cos(start)}
+		p2 := point{p3.x + k*math.Sin(end), p3.y - k*math.Cos(end)}
+
+		dst = flattenBezier(dst, p0, p1, p2, p3, flat, 0)
+	}
+
+	return dst
+}
+
+// degToRadians converts the given number of degrees to radians
+func degToRadians(degs float64) float64 {
+	return degs * math.Pi / 180
+}
+
+// arcAngles converts the angles (in degrees) arc and arcn are given to the
+// angles (in radians) the arc goes from and to
+// The second angle is brought within a whole turn of the first, past it
+// counter-clockwise or, for arcn, clockwise, as postscript does by adding or
+// subtracting multiples of 360 degrees: the sweep is reduced at once, and in
+// degrees, so that huge angles cost no more than small ones and whole turns
+// leave no rounding residue behind
+func arcAngles(angle1, angle2 float64, clockwise bool) (float64, float64, error) {
+	if math.IsInf(angle1, 0) || math.IsNaN(angle1) || math.IsInf(angle2, 0) || math.IsNaN(angle2) {
+		return 0, 0, fmt.Errorf("Range check: invalid arc angles %g and %g", angle1, angle2)
+	}
+
+	a1 := degToRadians(angle1)
+	sweep := angle2 - angle1
+	if sweep == 0 {
+		return a1, a1, nil
+	}
+
+	// within (0, 360] counter-clockwise, or [-360, 0) clockwise
+	sweep = math.Mod(sweep, 360)
+	if !clockwise && sweep <= 0 {
+		sweep += 360
+	}
+	if clockwise && sweep >= 0 {
+		sweep -= 360
+	}
+	return a1, a1 + degToRadians(sweep), nil
+}
+
+// curveTo appends a flattened Bézier curve from the current point to the
+// current path
+func (in *Interpreter) curveTo(p1, p2, p3 point) error {
+	gs := in.gs
+	for _, p := range flattenBezier(nil, gs.current, p1, p2, p3, gs.flat, 0) {
+		if err := in.lineTo(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// arc appends a flattened arc to the current path, connecting it to the
+// current point with a straight segment if there is one, as arc and arcn do
+// The angles are given in radians
+// A full circle starting a subpath of its own is marked as such, so that it
+// can be painted as a Circle
+func (in *Interpreter) arc(c point, r, a1, a2 float64) error {
+	gs := in.gs
+	start := arcPoint(c, r, a1)
+
+	// the circle starts a subpath of its own if there is no current point,
+	// or if the current point is where it starts anyway (i.e. after a
+	// moveto to that very point)
+	own := !gs.hasCur
+	if n := len(gs.path); gs.hasCur && n > 0 && len(gs.path[n-1].points) == 1 && !gs.path[n-1].closed {
+		own = math.Hypot(gs.current.x-start.x, gs.current.y-start.y) < 1e-9
+	}
+
+	if own {
+		in.moveTo(start)
+	} else if err := in.lineTo(start); err != nil {
+		return err
+	}
+
+	for _, p := range flattenArc(nil, c, r, a1, a2, gs.flat) {
+		if err := in.lineTo(p); err != nil {
+			return err
+		}
+	}
+
+	if own && math.Abs(a2-a1) >= 2*math.Pi-1e-9 {
+		sp := gs.path[len(gs.path)-1]
+		sp.circle = &circle{center: c, r: math.Abs(r), n: len(sp.points)}
+	}
+	return nil
+}
+
+// arcTo appends the arc of radius r tangent to both the line from the current
+// point to p1 and the line from p1 to p2, as arct does
+// The arc is preceded by a straight segment up to its first tangent point
+func (in *Interpreter) arcTo(p1, p2 point, r float64) error {
+	gs := in.gs
+	if !gs.hasCur {
+		return fmt.Errorf("No current point")
+	}
+	p0 := gs.current
+
+	// unit vectors from p1 towards p0 and p2
+	l0 := math.Hypot(p0.x-p1.x, p0.y-p1.y)
+	l2 := math.Hypot(p2.x-p1.x, p2.y-p1.y)
+	if l0 == 0 || l2 == 0 {
+		return in.lineTo(p1)
+	}
+	u := point{(p0.x - p1.x) / l0, (p0.y - p1.y) / l0}
+	v := point{(p2.x - p1.x) / l2, (p2.y - p1.y) / l2}
+
+	// collinear tangents leave no room for an arc
+	cross := u.x*v.y - u.y*v.x
+	if math.Abs(cross) < 1e-9 || r == 0 {
+		return in.lineTo(p1)
+	}
+
+	// the angle between the two tangents and the distance from p1 to the
+	// points where the circle touches them
+	phi := math.Acos(math.Max(-1, math.Min(1, u.x*v.x+u.y*v.y)))
+	d := r / math.Tan(phi/2)
+
+	t0 := point{p1.x + u.x*d, p1.y + u.y*d}
+	t2 := point{p1.x + v.x*d, p1.y + v.y*d}
+
+	// the center lies on the bisector, at a distance of r from both tangents
+	bisector := point{u.x + v.x, u.y + v.y}
+	bl := math.Hypot(bisector.x, bisector.y)
+	h := r / math.Sin(phi/2)
+	c := point{p1.x + bisector.x/bl*h, p1.y + bisector.y/bl*h}
+
+	a1 := math.Atan2(t0.y-c.y, t0.x-c.x)
+	a2 := math.Atan2(t2.y-c.y, t2.x-c.x)
+
+	// turning left at p1 (cross < 0) means the arc goes counter-clockwise
+	if cross < 0 {
+		for a2 < a1 {
+			a2 += 2 * math.Pi
+		}
+	} else {
+		for a2 > a1 {
+			a2 -= 2 * math.Pi
+		}
+	}
+
+	if err := in.lineTo(t0); err != nil {
+		return err
+	}
+	for _, p := range flattenArc(nil, c, r, a1, a2, gs.flat) {
+		if err := in.lineTo(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package postscript
+
+import (
+	"io" // for io.Reader and io.EOF
+
+	"./objects"
+)
+
+// Decoder reads a postscript program from an input stream and interprets it
+// as it goes, handing out the shapes it paints one at a time
+// Only the shapes painted but not yet handed out are ever kept in memory, so
+// arbitrarily large programs can be decoded while their shapes are rendered
+type Decoder struct {
+	in *Interpreter
+	pg *program
+
+	// the shapes painted but not yet handed out along with their pages,
+	// and the error which ended the decoding (io.EOF for a successfully
+	// finished program)
+	queue []objects.Shape
+	pages []int
+	err   error
+
+	// the page of the shape last handed out
+	page int
+}
+
+// NewDecoder returns a new Decoder reading from the given stream
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		in:    NewInterpreter(),
+		pg:    newProgram(r),
+		queue: []objects.Shape{},
+		pages: []int{},
+		page:  1,
+	}
+}
+
+// SetFlatness sets the flatness tolerance used for curves from here onward
+// It is equivalent to the program executing "f setflat"
+func (d *Decoder) SetFlatness(f float64) {
+	d.in.SetFlatness(f)
+}
+
+// SetDashMeasure sets how the lengths of dash patterns are measured from here
+// onward, postscript having no way to do so
+func (d *Decoder) SetDashMeasure(m objects.DashMeasure) {
+	d.in.SetDashMeasure(m)
+}
+
+// DSC returns the metadata found in the structural comments read so far
+// All of the header's metadata is available once the first shape is decoded
+func (d *Decoder) DSC() *DSC {
+	return d.in.DSC()
+}
+
+// Page returns the number of the page (starting from 1) which the shape last
+// returned by Next was painted on
+func (d *Decoder) Page() int {
+	return d.page
+}
+
+// PageCount returns the number of pages decoded so far: those ended by
+// showpage, plus the one being painted if it is not blank
+// Once Next has returned io.EOF, it is the number of pages of the document
+func (d *Decoder) PageCount() int {
+	return d.in.PageCount()
+}
+
+// Next interprets the program up until it paints its next shape and returns it
+// Once the whole program has been interpreted, it returns io.EOF
+func (d *Decoder) Next() (objects.Shape, error) {
+	for len(d.queue) == 0 {
+		if d.err != nil {
+			return nil, d.err
+		}
+
+		more, err := d.in.step(d.pg)
+		switch {
+		case err != nil:
+			d.err = err
+		case !more:
+			d.err = io.EOF
+		}
+
+		// take over whatever the step painted (a single stroke may
+		// paint several Lines) so the interpreter does not hoard it
+		d.queue = append(d.queue, d.in.shapes...)
+		d.pages = append(d.pages, d.in.pages...)
+		d.in.shapes = d.in.shapes[:0]
+		d.in.pages = d.in.pages[:0]
+	}
+
+	shape := d.queue[0]
+	d.page = d.pages[0]
+	d.queue = d.queue[1:]
+	d.pages = d.pages[1:]
+	return shape, nil
+}
+
+// Walk decodes the whole program, calling fn on each shape as soon as it is
+// painted; it stops at the first error returned by either fn or the decoding
+func (d *Decoder) Walk(fn func(shape objects.Shape) error) error {
+	return d.WalkPages(func(page int, shape objects.Shape) error {
+		return fn(shape)
+	})
+}
+
+// WalkPages is like Walk, but also hands fn the number of the page each shape
+// was painted on
+// Pages left blank are never seen by fn; use PageCount to account for them
+func (d *Decoder) WalkPages(fn func(page int, shape objects.Shape) error) error {
+	for {
+		shape, err := d.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := fn(d.page, shape); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package postscript
+
+import (
+	"bufio"   // for bufio.Scanner
+	"fmt"     // for fmt.Errorf
+	"io"      // for io.Reader
+	"math"    // for math.Ceil
+	"os"      // for os.Open
+	"strconv" // for strconv.Atoi and strconv.ParseFloat
+	"strings" // for string trimming and splitting
+
+	"./objects"
+)
+
+// atend is the value of DSC comments deferred to the document's trailer
+const atend = "(atend)"
+
+// Box is a rectangle defined by its lower left and upper right corners, as
+// used by the %%BoundingBox comments
+type Box struct {
+	LLX, LLY, URX, URY float64
+}
+
+// String satisfies fmt.Stringer.
+func (b *Box) String() string {
+	return fmt.Sprintf("[(%g, %g) - (%g, %g)]", b.LLX, b.LLY, b.URX, b.URY)
+}
+
+// Width returns the width of the box
+func (b *Box) Width() float64 {
+	return b.URX - b.LLX
+}
+
+// Height returns the height of the box
+func (b *Box) Height() float64 {
+	return b.URY - b.LLY
+}
+
+// Size returns the dimensions of the smallest bitmap the whole box fits in,
+// once its lower left corner is moved onto the origin of the bitmap (see
+// TransformPoint)
+// Its pixels lie at the whole coordinates from 0 to the box's width and
+// height, both edges of the box included, and so there is one more of them
+// than the box is wide and high
+func (b *Box) Size() (width, height int) {
+	return int(math.Ceil(b.Width())) + 1, int(math.Ceil(b.Height())) + 1
+}
+
+// TransformPoint moves the given point along with the box, so that its lower
+// left corner lies at the origin, satisfying the objects.Transformer
+// interface
+// Shapes transformed this way are drawn onto a bitmap of the box's Size
+func (b *Box) TransformPoint(p *objects.Point) *objects.Point {
+	return objects.NewPoint(p.X-b.LLX, p.Y-b.LLY)
+}
+
+// DSC holds the metadata of a postscript document given as Document
+// Structuring Conventions comments (i.e. %%BoundingBox: 0 0 200 200)
+type DSC struct {
+	// Version is the DSC conformance level of the document (i.e. 3.0 for
+	// a document starting with %!PS-Adobe-3.0); empty if it does not conform
+	Version string
+
+	// EPSVersion is the EPSF version of an Encapsulated PostScript file (i.e.
+	// 3.0 for one starting with %!PS-Adobe-3.0 EPSF-3.0); empty otherwise
+	EPSVersion string
+
+	// BoundingBox and HiResBoundingBox are nil unless the document has them
+	BoundingBox      *Box
+	HiResBoundingBox *Box
+
+	// Pages is the number of pages declared by %%Pages, or 0 if undeclared
+	Pages int
+
+	Title        string
+	Creator      string
+	CreationDate string
+
+	// Comments holds the values of all the header's comments by keyword,
+	// including the ones already parsed into the fields above
+	Comments map[string]string
+
+	// Warnings holds an error for each malformed comment which was skipped
+	// (i.e. a %%BoundingBox without four numbers), the document being
+	// read on regardless
+	Warnings []error
+
+	// whether we are within the document's header or trailer
+	header  bool
+	trailer bool
+}
+
+// NewDSC returns an empty DSC, ready to start reading a document's header
+func NewDSC() *DSC {
+	return &DSC{
+		Comments: make(map[string]string),
+		header:   true,
+	}
+}
+
+// IsEPS returns true if the document is an Encapsulated PostScript file
+func (d *DSC) IsEPS() bool {
+	return d.EPSVersion != ""
+}
+
+// Bounds returns the most precise bounding box the document declares, or nil
+// if it declares none
+func (d *DSC) Bounds() *Box {
+	if d.HiResBoundingBox != nil {
+		return d.HiResBoundingBox
+	}
+	return d.BoundingBox
+}
+
+// parseBox parses the four numbers of a bounding box comment's value
+func parseBox(value string) (*Box, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("Invalid bounding box %q", value)
+	}
+
+	nums, err := atofs(fields)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid bounding box %q: %s", value, err)
+	}
+
+	return &Box{nums[0], nums[1], nums[2], nums[3]}, nil
+}
+
+// atofs takes a slice of string representations of numbers and parses each,
+// returning the resulting list
+// if any error occurs for a conversion; the function will promptly return it
+func atofs(strs []string) ([]float64, error) {
+	floats := []float64{}
+
+	for _, str := range strs {
+		n, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return nil, err
+		}
+		floats = append(floats, n)
+	}
+
+	return floats, nil
+}
+
+// endHeader marks the end of the document's header
+// It is called on %%EndComments or on the first line of actual code
+func (d *DSC) endHeader() {
+	d.header = false
+}
+
+// parseComment interprets a single structural comment
+// Comments outside of the header and trailer are ignored, and malformed ones
+// are only added to the warnings, leaving the fields they set untouched
+func (d *DSC) parseComment(line string) {
+	// the very first line gives the version: %!PS-Adobe-3.0 [EPSF-3.0]
+	if strings.HasPrefix(line, "%!") {
+		fields := strings.Fields(line[2:])
+		if len(fields) > 0 && strings.HasPrefix(fields[0], "PS-Adobe-") {
+			d.Version = strings.TrimPrefix(fields[0], "PS-Adobe-")
+		}
+		if len(fields) > 1 && strings.HasPrefix(fields[1], "EPSF-") {
+			d.EPSVersion = strings.TrimPrefix(fields[1], "EPSF-")
+		}
+		return
+	}
+
+	// split the comment into its keyword and value
+	line = strings.TrimPrefix(line, "%%")
+	keyword, value := line, ""
+	if i := strings.Index(line, ":"); i >= 0 {
+		keyword, value = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	switch keyword {
+	case "EndComments":
+		d.endHeader()
+		return
+	case "Trailer":
+		d.header = false
+		d.trailer = true
+		return
+	}
+
+	// only the header's comments are of interest, along with the ones in
+	// the trailer which the header deferred there with (atend)
+	switch {
+	case d.header:
+		d.Comments[keyword] = value
+	case d.trailer && d.Comments[keyword] == atend:
+		d.Comments[keyword] = value
+	default:
+		return
+	}
+
+	// values deferred to the trailer are filled in once we get there
+	if value == atend {
+		return
+	}
+
+	var err error
+	switch keyword {
+	case "BoundingBox", "HiResBoundingBox":
+		var box *Box
+		if box, err = parseBox(value); err != nil {
+			break
+		}
+		if keyword == "BoundingBox" {
+			d.BoundingBox = box
+		} else {
+			d.HiResBoundingBox = box
+		}
+	case "Pages":
+		// the page order may follow the number of pages
+		fields := strings.Fields(value)
+		if len(fields) > 0 {
+			var pages int
+			if pages, err = strconv.Atoi(fields[0]); err == nil {
+				d.Pages = pages
+			}
+		}
+	case "Title":
+		d.Title = value
+	case "Creator":
+		d.Creator = value
+	case "CreationDate":
+		d.CreationDate = value
+	}
+
+	if err != nil {
+		d.Warnings = append(d.Warnings, fmt.Errorf("Skipping malformed %%%%%s comment: %s", keyword, err))
+	}
+}
+
+// ReadDSC reads the DSC metadata of the postscript document from the given
+// reader, without interpreting any of its code
+func ReadDSC(r io.Reader) (*DSC, error) {
+	d := NewDSC()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "%%") || strings.HasPrefix(line, "%!"):
+			d.parseComment(line)
+		case d.header && strings.TrimSpace(line) != "":
+			// the header ends at the first line of code
+			d.endHeader()
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// ReadDSCFile reads the DSC metadata of the given postscript file
+func ReadDSCFile(filename string) (*DSC, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadDSC(f)
+}
@@ -0,0 +1,68 @@
+package postscript
+
+import (
+	"strings"
+	"testing"
+
+	"./objects"
+)
+
+func TestReadDSCMalformedComments(t *testing.T) {
+	src := `%!PS-Adobe-3.0
+%%BoundingBox: 0 0 oops
+%%HiResBoundingBox: 10 20 110.5 70
+%%Pages: many
+%%Title: malformed
+%%EndComments
+0 0 moveto 10 10 lineto stroke
+`
+	d, err := ReadDSC(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ReadDSC: %s", err)
+	}
+
+	if d.BoundingBox != nil {
+		t.Errorf("malformed bounding box read as %s", d.BoundingBox)
+	}
+	if b := d.HiResBoundingBox; b == nil || *b != (Box{10, 20, 110.5, 70}) {
+		t.Errorf("hires bounding box is %v, want [(10, 20) - (110.5, 70)]", b)
+	}
+	if d.Pages != 0 || d.Title != "malformed" {
+		t.Errorf("pages %d and title %q, want 0 and \"malformed\"", d.Pages, d.Title)
+	}
+	if len(d.Warnings) != 2 {
+		t.Errorf("%d warnings, want 2: %v", len(d.Warnings), d.Warnings)
+	}
+
+	// the document itself is read on regardless
+	if points := parseSource(t, src); len(points) != 2 {
+		t.Errorf("document with malformed comments yields %d line ends, want 2", len(points))
+	}
+}
+
+func TestBoxSize(t *testing.T) {
+	tests := []struct {
+		box           Box
+		width, height int
+	}{
+		{Box{0, 0, 200, 100}, 201, 101},
+		{Box{50, 60, 150, 110}, 101, 51},
+		{Box{-10, -20, 10.5, 20}, 22, 41},
+	}
+
+	for _, test := range tests {
+		if w, h := test.box.Size(); w != test.width || h != test.height {
+			t.Errorf("box %s is %dx%d, want %dx%d", &test.box, w, h, test.width, test.height)
+		}
+	}
+
+	// both corners are moved onto the corner pixels of the bitmap
+	b := &Box{50, 60, 150, 110}
+	width, height := b.Size()
+	if p := b.TransformPoint(objects.NewPoint(50, 60)); p.X != 0 || p.Y != 0 {
+		t.Errorf("lower left corner is moved to (%g, %g), want (0, 0)", p.X, p.Y)
+	}
+	if p := b.TransformPoint(objects.NewPoint(150, 110)); p.X != float64(width-1) || p.Y != float64(height-1) {
+		t.Errorf("upper right corner is moved to (%g, %g), want (%d, %d)", p.X, p.Y, width-1, height-1)
+	}
+}
@@ -0,0 +1,598 @@
+package postscript
+
+import (
+	"bytes" // for bytes.NewReader
+	"fmt"   // for fmt.Errorf
+	"io"    // for io.Reader
+
+	"../clipping"
+	"./objects"
+)
+
+// name is an executable postscript name, looked up and run when executed
+type name string
+
+// literal is a literal postscript name (i.e. /Line), pushed as-is
+type literal string
+
+// procedure is an executable array of postscript objects ({ ... })
+type procedure []interface{}
+
+// array is a literal postscript array ([ ... ])
+type array []interface{}
+
+// mark is the object [ pushes to mark the start of an array on the stack
+type mark struct{}
+
+// operator is a builtin postscript operator
+type operator func(in *Interpreter) error
+
+// point is a point in postscript user space
+type point struct {
+	x, y float64
+}
+
+// subpath is a connected sequence of points started by a moveto
+type subpath struct {
+	points []point
+	closed bool
+
+	// circle is set if the subpath is nothing but a full circle drawn
+	// by arc, so that it can be painted as such rather than flattened
+	circle *circle
+}
+
+// circle is a full circle traced by arc or arcn
+type circle struct {
+	center point
+	r      float64
+
+	// the number of points of the subpath once the circle was traced;
+	// anything appended afterwards makes it more than a circle
+	n int
+}
+
+// isCircle returns true if the subpath is a full circle and nothing more
+func (sp *subpath) isCircle() bool {
+	return sp.circle != nil && len(sp.points) == sp.circle.n
+}
+
+// gstate is the subset of the postscript graphics state we keep track of
+type gstate struct {
+	// the current path and current point
+	path    []*subpath
+	current point
+	hasCur  bool
+
+	// the flatness tolerance used when flattening curves
+	flat float64
+
+	// the line width, caps, joins and miter limit paths are stroked with
+	stroke objects.Stroke
+
+	// the current font, as set by setfont
+	font *font
+
+	// the clipping paths painted shapes are clipped to, as intersected by
+	// clip and reset by initclip
+	clip *clipping.ClipStack
+}
+
+// newGstate returns the graphics state new Interpreters start with
+func newGstate() *gstate {
+	return &gstate{
+		flat:   DefaultFlatness,
+		stroke: *objects.NewStroke(1),
+		clip:   clipping.NewClipStack(),
+	}
+}
+
+// copy returns a deep copy of the graphics state, as used by gsave
+func (gs *gstate) copy() *gstate {
+	res := *gs
+	res.path = make([]*subpath, len(gs.path))
+	for i, sp := range gs.path {
+		res.path[i] = &subpath{
+			points: append([]point{}, sp.points...),
+			closed: sp.closed,
+			circle: sp.circle,
+		}
+	}
+	res.clip = gs.clip.Copy()
+	return &res
+}
+
+// DefaultFlatness is the flatness tolerance new Interpreters start with
+// It is the maximum distance (in pixels) a flattened curve may stray from
+// the true curve, as in postscript's setflat
+var DefaultFlatness = 1.0
+
+// Interpreter is a small postscript interpreter which understands enough of
+// the language (numbers, procedures, def, path construction, clipping and
+// painting operators) to reduce a program to the Lines it strokes and the
+// Polygons it fills
+type Interpreter struct {
+	// the operand stack
+	stack []interface{}
+
+	// user definitions; looked up before the builtin operators
+	userdict map[string]interface{}
+
+	// the current graphics state and the ones saved by gsave
+	gs     *gstate
+	gsaves []*gstate
+
+	// everything painted so far, in painting order, along with the number
+	// of the page each shape was painted on
+	shapes []objects.Shape
+	pages  []int
+
+	// the number of the page being painted, starting from 1, and whether
+	// anything has been painted on it yet
+	page    int
+	painted bool
+
+	// the metadata found in the programs' structural comments
+	dsc *DSC
+}
+
+// NewInterpreter returns a new Interpreter with an empty state
+func NewInterpreter() *Interpreter {
+	return &Interpreter{
+		stack:    []interface{}{},
+		userdict: make(map[string]interface{}),
+		gs:       newGstate(),
+		gsaves:   []*gstate{},
+		shapes:   []objects.Shape{},
+		pages:    []int{},
+		page:     1,
+		dsc:      NewDSC(),
+	}
+}
+
+// SetFlatness sets the flatness tolerance used for curves from here onward
+// It is equivalent to executing "f setflat"
+func (in *Interpreter) SetFlatness(f float64) {
+	in.gs.flat = clampFlatness(f)
+}
+
+// SetDashMeasure sets how the lengths of dash patterns set from here onward
+// are measured; postscript measures them by length, which is the default
+func (in *Interpreter) SetDashMeasure(m objects.DashMeasure) {
+	in.gs.stroke.DashMeasure = m
+}
+
+// Lines returns all the Lines stroked by the programs executed so far
+func (in *Interpreter) Lines() []*objects.Line {
+	return ShapesToLines(in.shapes)
+}
+
+// Shapes returns everything painted by the programs executed so far, in the
+// order in which it was painted; that is, stroked Lines and filled Polygons
+func (in *Interpreter) Shapes() []objects.Shape {
+	return in.shapes
+}
+
+// Pages returns everything painted by the programs executed so far, split up
+// into the pages ended by showpage; pages left blank are empty slices
+// There is always at least one page, even if nothing was painted at all
+func (in *Interpreter) Pages() [][]objects.Shape {
+	pages := make([][]objects.Shape, in.PageCount())
+	for i := range pages {
+		pages[i] = []objects.Shape{}
+	}
+	for i, shape := range in.shapes {
+		pages[in.pages[i]-1] = append(pages[in.pages[i]-1], shape)
+	}
+	return pages
+}
+
+// PageCount returns the number of pages of the programs executed so far:
+// those ended by showpage, plus the one being painted if it is not blank
+// A program which never calls showpage has a single page
+func (in *Interpreter) PageCount() int {
+	if in.painted || in.page == 1 {
+		return in.page
+	}
+	return in.page - 1
+}
+
+// DSC returns the metadata found in the structural comments of the programs
+// executed so far
+func (in *Interpreter) DSC() *DSC {
+	return in.dsc
+}
+
+// paint records the given shape as having been painted, or rather the parts
+// of it within the current clipping path
+func (in *Interpreter) paint(shape objects.Shape) error {
+	parts := []objects.Shape{shape}
+	if in.gs.clip.Depth() > 0 {
+		var err error
+		if parts, err = shape.Clip(in.gs.clip); err != nil {
+			return err
+		}
+	}
+
+	for _, part := range parts {
+		in.shapes = append(in.shapes, part)
+		in.pages = append(in.pages, in.page)
+	}
+	in.painted = true
+	return nil
+}
+
+// showPage ends the current page and starts painting on a blank one
+// As with postscript's showpage, the current path is cleared as well
+func (in *Interpreter) showPage() {
+	in.page++
+	in.painted = false
+	in.newPath()
+}
+
+// Execute runs the given postscript source code
+func (in *Interpreter) Execute(src []byte) error {
+	pg := newProgram(bytes.NewReader(src))
+
+	for {
+		more, err := in.step(pg)
+		if err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// program is a postscript program being read from a stream
+type program struct {
+	lx *lexer
+
+	// procs holds the procedures currently being built, innermost last
+	procs []procedure
+}
+
+// newProgram returns a new program to be read from the given stream
+func newProgram(r io.Reader) *program {
+	return &program{
+		lx:    newLexer(r),
+		procs: []procedure{},
+	}
+}
+
+// step reads the next token of the given program and runs it
+// It returns false once the whole program has been run
+func (in *Interpreter) step(pg *program) (bool, error) {
+	tok, ok, err := pg.lx.next()
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		if len(pg.procs) > 0 {
+			return false, fmt.Errorf("Unterminated procedure")
+		}
+		return false, nil
+	}
+
+	// structural comments are only of interest for their metadata,
+	// and the first bit of code marks the end of the header
+	if tok.kind == tokComment {
+		in.dsc.parseComment(tok.text)
+		return true, nil
+	}
+	in.dsc.endHeader()
+
+	var obj interface{}
+	switch tok.kind {
+	case tokNumber:
+		obj = tok.num
+	case tokLiteral:
+		obj = literal(tok.text)
+	case tokString:
+		obj = tok.text
+	case tokName:
+		obj = name(tok.text)
+	case tokProcBegin:
+		pg.procs = append(pg.procs, procedure{})
+		return true, nil
+	case tokProcEnd:
+		if len(pg.procs) == 0 {
+			return false, fmt.Errorf("Unmatched }")
+		}
+		obj = pg.procs[len(pg.procs)-1]
+		pg.procs = pg.procs[:len(pg.procs)-1]
+	}
+
+	// objects within procedures are deferred until the procedure is run
+	if len(pg.procs) > 0 {
+		pg.procs[len(pg.procs)-1] = append(pg.procs[len(pg.procs)-1], obj)
+		return true, nil
+	}
+
+	// procedures read directly are pushed, not run
+	if proc, ok := obj.(procedure); ok {
+		in.push(proc)
+		return true, nil
+	}
+
+	return true, in.execute(obj)
+}
+
+// execute runs a single object as it is encountered in a program
+func (in *Interpreter) execute(obj interface{}) error {
+	switch o := obj.(type) {
+	case name:
+		val, err := in.lookup(string(o))
+		if err != nil {
+			return err
+		}
+		return in.call(val)
+	default:
+		in.push(o)
+	}
+
+	return nil
+}
+
+// call runs a value bound to a name
+func (in *Interpreter) call(val interface{}) error {
+	switch v := val.(type) {
+	case operator:
+		return v(in)
+	case procedure:
+		for _, obj := range v {
+			// nested procedures are pushed, not run
+			if proc, ok := obj.(procedure); ok {
+				in.push(proc)
+				continue
+			}
+			if err := in.execute(obj); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		in.push(v)
+	}
+
+	return nil
+}
+
+// lookup returns the value bound to the given name
+func (in *Interpreter) lookup(n string) (interface{}, error) {
+	if val, ok := in.userdict[n]; ok {
+		return val, nil
+	}
+	if op, ok := systemdict[n]; ok {
+		return op, nil
+	}
+
+	return nil, fmt.Errorf("Undefined name %q", n)
+}
+
+// push pushes the given object onto the operand stack
+func (in *Interpreter) push(obj interface{}) {
+	in.stack = append(in.stack, obj)
+}
+
+// pop pops the topmost object off the operand stack
+func (in *Interpreter) pop() (interface{}, error) {
+	if len(in.stack) == 0 {
+		return nil, fmt.Errorf("Stack underflow")
+	}
+
+	obj := in.stack[len(in.stack)-1]
+	in.stack = in.stack[:len(in.stack)-1]
+	return obj, nil
+}
+
+// popNumbers pops n numbers off the operand stack and returns them in the
+// order in which they were pushed
+func (in *Interpreter) popNumbers(n int) ([]float64, error) {
+	if len(in.stack) < n {
+		return nil, fmt.Errorf("Stack underflow")
+	}
+
+	nums := make([]float64, n)
+	for i, obj := range in.stack[len(in.stack)-n:] {
+		num, ok := obj.(float64)
+		if !ok {
+			return nil, fmt.Errorf("Type check: expected number, got %v", obj)
+		}
+		nums[i] = num
+	}
+
+	in.stack = in.stack[:len(in.stack)-n]
+	return nums, nil
+}
+
+// moveTo starts a new subpath at the given point
+func (in *Interpreter) moveTo(p point) {
+	gs := in.gs
+
+	// consecutive movetos simply replace one another
+	if n := len(gs.path); n > 0 && len(gs.path[n-1].points) == 1 && !gs.path[n-1].closed {
+		gs.path[n-1].points[0] = p
+	} else {
+		gs.path = append(gs.path, &subpath{points: []point{p}})
+	}
+
+	gs.current = p
+	gs.hasCur = true
+}
+
+// lineTo appends a straight segment to the current subpath
+func (in *Interpreter) lineTo(p point) error {
+	gs := in.gs
+	if !gs.hasCur {
+		return fmt.Errorf("No current point")
+	}
+
+	// a closed subpath is continued by a new one from its start point
+	sp := gs.path[len(gs.path)-1]
+	if sp.closed {
+		sp = &subpath{points: []point{gs.current}}
+		gs.path = append(gs.path, sp)
+	}
+
+	sp.points = append(sp.points, p)
+	gs.current = p
+	return nil
+}
+
+// closePath closes the current subpath, returning to its start
+func (in *Interpreter) closePath() {
+	gs := in.gs
+	if len(gs.path) == 0 {
+		return
+	}
+
+	sp := gs.path[len(gs.path)-1]
+	sp.closed = true
+	gs.current = sp.points[0]
+}
+
+// newPath clears the current path and current point
+func (in *Interpreter) newPath() {
+	in.gs.path = []*subpath{}
+	in.gs.hasCur = false
+}
+
+// toPoint converts the given point to an objects.Point
+func toPoint(p point) *objects.Point {
+	return objects.NewPoint(p.x, p.y)
+}
+
+// toCircle converts the given full circle to an objects.Circle
+func toCircle(c *circle) *objects.Circle {
+	return objects.NewCircle(toPoint(c.center), c.r)
+}
+
+// strokePath paints all the segments of the current path as Lines, except
+// for full circles which are painted as Circles
+// Each segment is painted from its end back to its start: "x1 y1 x2 y2 Line"
+// moves to x2 y2 and draws to x1 y1, and is still read as the Line from
+// (x1, y1) to (x2, y2) as it always has been
+// If the line width is thick or there is a dash pattern, each subpath is
+// painted as a Polyline with the current stroke instead, for its caps, joins
+// and dashes to be painted too
+func (in *Interpreter) strokePath() error {
+	if in.gs.stroke.IsThick() || in.gs.stroke.IsDashed() {
+		return in.strokeStyled()
+	}
+
+	for _, sp := range in.gs.path {
+		if sp.isCircle() {
+			if err := in.paint(toCircle(sp.circle)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		pts := sp.points
+		if sp.closed && len(pts) > 1 {
+			pts = append(pts[:len(pts):len(pts)], pts[0])
+		}
+
+		for i := 1; i < len(pts); i++ {
+			if err := in.paint(objects.NewLine(toPoint(pts[i]), toPoint(pts[i-1]))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// strokeStyled paints every subpath of the current path as a Polyline with
+// the current stroke
+func (in *Interpreter) strokeStyled() error {
+	for _, sp := range in.gs.path {
+		if len(sp.points) < 2 {
+			continue
+		}
+
+		points := make([]*objects.Point, len(sp.points))
+		for i, p := range sp.points {
+			points[i] = toPoint(p)
+		}
+
+		stroke := in.gs.stroke
+		err := in.paint(&objects.Polyline{
+			Points: points,
+			Closed: sp.closed || sp.isCircle(),
+			Stroke: &stroke,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fillPath paints the area enclosed by the current path using the given
+// fill rule; all of the path's subpaths are implicitly closed
+// A path made up of a single full circle is painted as a filled Circle
+func (in *Interpreter) fillPath(rule objects.FillRule) error {
+	if len(in.gs.path) == 1 && in.gs.path[0].isCircle() {
+		c := toCircle(in.gs.path[0].circle)
+		c.Filled = true
+		return in.paint(c)
+	}
+
+	if p := in.pathPolygon(rule); len(p.Rings) > 0 {
+		return in.paint(p)
+	}
+	return nil
+}
+
+// pathPolygon returns the Polygon enclosed by the current path using the
+// given fill rule, all of the path's subpaths being implicitly closed
+func (in *Interpreter) pathPolygon(rule objects.FillRule) *objects.Polygon {
+	rings := [][]*objects.Point{}
+	for _, sp := range in.gs.path {
+		ring := make([]*objects.Point, len(sp.points))
+		for i, p := range sp.points {
+			ring[i] = toPoint(p)
+		}
+		rings = append(rings, ring)
+	}
+	return objects.NewPolygon(rule, rings...)
+}
+
+// clipPath intersects the current clipping path with the area enclosed by
+// the current path using the given fill rule, as postscript's clip and
+// eoclip do
+// A lone axis-aligned rectangle clips with a Window, exactly for curves as
+// well; any other path with a PathWindow
+func (in *Interpreter) clipPath(rule objects.FillRule) {
+	p := in.pathPolygon(rule)
+	if len(p.Rings) == 1 && isRect(p.Rings[0]) {
+		b := p.Bounds()
+		in.gs.clip.Push(clipping.NewWindow(b.LLX, b.LLY, b.URX, b.URY))
+		return
+	}
+	in.gs.clip.Push(clipping.NewPathWindow(p))
+}
+
+// isRect returns true if the given ring is an axis-aligned rectangle
+func isRect(ring []*objects.Point) bool {
+	if len(ring) == 5 && ring[4].X == ring[0].X && ring[4].Y == ring[0].Y {
+		ring = ring[:4]
+	}
+	if len(ring) != 4 {
+		return false
+	}
+
+	for i, a := range ring {
+		b := ring[(i+1)%4]
+		c := ring[(i+2)%4]
+
+		// each side turns a right angle into the next, alternating
+		// between horizontal and vertical
+		if (a.X == b.X) == (b.X == c.X) || (a.Y == b.Y) == (b.Y == c.Y) {
+			return false
+		}
+	}
+	return true
+}
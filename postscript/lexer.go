@@ -0,0 +1,326 @@
+package postscript
+
+import (
+	"bufio"   // for bufio.Reader
+	"fmt"     // for fmt.Errorf
+	"io"      // for io.Reader and io.EOF
+	"strconv" // for strconv.ParseFloat
+)
+
+// tokenKind enumerates the different kinds of lexical tokens
+type tokenKind int
+
+const (
+	// tokNumber is an integer or real number literal
+	tokNumber tokenKind = iota
+
+	// tokName is an executable name (i.e. moveto, Line, or the [ and ]
+	// array brackets)
+	tokName
+
+	// tokLiteral is a literal name (i.e. /Line)
+	tokLiteral
+
+	// tokProcBegin and tokProcEnd delimit procedure bodies ({ and })
+	tokProcBegin
+	tokProcEnd
+
+	// tokString is a string literal (i.e. (Hello world)); its text holds the
+	// string's contents with all escapes resolved
+	tokString
+
+	// tokComment is a structural comment (one starting with %% or %! at the
+	// beginning of a line) which may hold DSC metadata
+	tokComment
+)
+
+// token is a single lexical unit of a postscript program
+type token struct {
+	kind tokenKind
+
+	// the raw text of the token
+	text string
+
+	// the parsed value of number tokens
+	num float64
+}
+
+// isDelimiter returns true if the given byte is a postscript delimiter
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// isSpace returns true if the given byte is postscript whitespace
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '\f', 0:
+		return true
+	}
+	return false
+}
+
+// isNumberStart returns true if the given byte may begin a number literal
+func isNumberStart(c byte) bool {
+	return (c >= '0' && c <= '9') || c == '+' || c == '-' || c == '.'
+}
+
+// lexer splits a stream of postscript source code into tokens
+// It reads ahead by at most one byte, so that tokens can be handed out as
+// soon as they are read
+type lexer struct {
+	r *bufio.Reader
+
+	// the offset of the next byte in the stream and the byte preceding it
+	pos  int
+	prev byte
+}
+
+// newLexer returns a lexer positioned at the start of the given stream
+func newLexer(r io.Reader) *lexer {
+	// the start of the stream counts as the start of a line
+	return &lexer{r: bufio.NewReader(r), prev: '\n'}
+}
+
+// peek returns the next byte in the stream without consuming it
+// ok is false once the end of the stream has been reached
+func (lx *lexer) peek() (c byte, ok bool, err error) {
+	c, err = lx.r.ReadByte()
+	if err == io.EOF {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return c, true, lx.r.UnreadByte()
+}
+
+// advance consumes the next byte in the stream, which must have been peeked
+func (lx *lexer) advance() {
+	lx.prev, _ = lx.r.ReadByte()
+	lx.pos++
+}
+
+// atStructuralComment returns true if the lexer is positioned at the start
+// of a structural comment
+// The opening % of the comment must have been peeked
+func (lx *lexer) atStructuralComment() (bool, error) {
+	if lx.prev != '\n' && lx.prev != '\r' {
+		return false, nil
+	}
+
+	next, err := lx.r.Peek(2)
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return next[1] == '%' || next[1] == '!', nil
+}
+
+// read consumes all the bytes up until the first one failing the given test
+func (lx *lexer) read(accept func(c byte) bool) (string, error) {
+	buf := []byte{}
+
+	for {
+		c, ok, err := lx.peek()
+		if err != nil {
+			return "", err
+		}
+		if !ok || !accept(c) {
+			return string(buf), nil
+		}
+
+		buf = append(buf, c)
+		lx.advance()
+	}
+}
+
+// comment reads a comment up until the end of its line
+func (lx *lexer) comment() (string, error) {
+	return lx.read(func(c byte) bool {
+		return c != '\n' && c != '\r'
+	})
+}
+
+// regular reads a run of regular (non-delimiter, non-whitespace) characters
+func (lx *lexer) regular() (string, error) {
+	return lx.read(func(c byte) bool {
+		return !isSpace(c) && !isDelimiter(c)
+	})
+}
+
+// skip advances past all whitespace and regular comments, stopping at
+// any structural comment
+func (lx *lexer) skip() error {
+	for {
+		c, ok, err := lx.peek()
+		if err != nil || !ok {
+			return err
+		}
+
+		switch {
+		case isSpace(c):
+			lx.advance()
+		case c == '%':
+			structural, err := lx.atStructuralComment()
+			if err != nil || structural {
+				return err
+			}
+			if _, err := lx.comment(); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// escapes maps the characters following a backslash in a string literal to
+// the characters they stand for
+var escapes = map[byte]byte{
+	'n':  '\n',
+	'r':  '\r',
+	't':  '\t',
+	'b':  '\b',
+	'f':  '\f',
+	'\\': '\\',
+	'(':  '(',
+	')':  ')',
+}
+
+// str reads a string literal, the opening parenthesis of which must have
+// been consumed already
+// Balanced pairs of parentheses may appear unescaped within the string
+func (lx *lexer) str() (string, error) {
+	buf := []byte{}
+	depth := 1
+
+	for {
+		c, ok, err := lx.peek()
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", fmt.Errorf("Unterminated string at offset %d", lx.pos)
+		}
+		lx.advance()
+
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return string(buf), nil
+			}
+		case '\\':
+			c, ok, err = lx.peek()
+			if err != nil {
+				return "", err
+			}
+			if !ok {
+				continue
+			}
+			lx.advance()
+
+			// a backslash before a newline continues the line
+			if c == '\n' || c == '\r' {
+				continue
+			}
+
+			// up to three octal digits give a character code
+			if c >= '0' && c <= '7' {
+				code := int(c - '0')
+				for i := 0; i < 2; i++ {
+					d, ok, err := lx.peek()
+					if err != nil {
+						return "", err
+					}
+					if !ok || d < '0' || d > '7' {
+						break
+					}
+					lx.advance()
+					code = code*8 + int(d-'0')
+				}
+				buf = append(buf, byte(code))
+				continue
+			}
+
+			// unknown escapes simply stand for the escaped character
+			if e, ok := escapes[c]; ok {
+				c = e
+			}
+		}
+
+		buf = append(buf, c)
+	}
+}
+
+// next returns the next token in the stream
+// ok is false once the end of the stream has been reached
+func (lx *lexer) next() (tok token, ok bool, err error) {
+	if err := lx.skip(); err != nil {
+		return token{}, false, err
+	}
+
+	c, ok, err := lx.peek()
+	if err != nil || !ok {
+		return token{}, false, err
+	}
+
+	switch c {
+	case '%':
+		text, err := lx.comment()
+		return token{kind: tokComment, text: text}, err == nil, err
+	case '(':
+		lx.advance()
+		text, err := lx.str()
+		return token{kind: tokString, text: text}, err == nil, err
+	case '{':
+		lx.advance()
+		return token{kind: tokProcBegin, text: "{"}, true, nil
+	case '}':
+		lx.advance()
+		return token{kind: tokProcEnd, text: "}"}, true, nil
+	case '[', ']':
+		// array brackets are operators in their own right
+		lx.advance()
+		return token{kind: tokName, text: string(c)}, true, nil
+	case '/':
+		lx.advance()
+		name, err := lx.regular()
+		if err != nil {
+			return token{}, false, err
+		}
+		if name == "" {
+			return token{}, false, fmt.Errorf("Empty literal name at offset %d", lx.pos)
+		}
+		return token{kind: tokLiteral, text: name}, true, nil
+	}
+
+	if isDelimiter(c) {
+		return token{}, false, fmt.Errorf("Unsupported syntax %q at offset %d", c, lx.pos)
+	}
+
+	text, err := lx.regular()
+	if err != nil {
+		return token{}, false, err
+	}
+
+	// anything starting like a number and parsing as one is a number
+	// the rest are all names
+	if isNumberStart(text[0]) {
+		if n, err := strconv.ParseFloat(text, 64); err == nil {
+			return token{kind: tokNumber, text: text, num: n}, true, nil
+		}
+	}
+
+	return token{kind: tokName, text: text}, true, nil
+}
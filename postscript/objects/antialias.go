@@ -0,0 +1,141 @@
+package objects
+
+import (
+	"math"
+)
+
+// AntialiasedDrawable is implemented by the shapes which can also be drawn
+// anti-aliased, blending their edges into the background
+type AntialiasedDrawable interface {
+	DrawAntialiased(c BlendCanvas, color string) error
+}
+
+// DrawAntialiased draws the given shape anti-aliased on the Canvas if both of
+// them support it, and with its plain Draw method otherwise
+func DrawAntialiased(s Drawable, c Canvas, color string) error {
+	ad, ok := s.(AntialiasedDrawable)
+	bc, isBlend := c.(BlendCanvas)
+	if !ok || !isBlend {
+		return s.Draw(c, color)
+	}
+	return ad.DrawAntialiased(bc, color)
+}
+
+// blendPlot blends a single pixel into the canvas, leaving out the pixels
+// outside of it, just as plot does
+func blendPlot(c BlendCanvas, x, y int, color string, alpha float64) error {
+	if x < 0 || y < 0 || x >= c.Width() || y >= c.Height() {
+		return nil
+	}
+	return c.BlendPixelCartesian(x, y, color, alpha)
+}
+
+// fpart returns the fractional part of x
+func fpart(x float64) float64 {
+	return x - math.Floor(x)
+}
+
+// DrawAntialiased takes a BlendCanvas as parameter and proceeds to draw this
+// line on it using the color code provided using Wu's algorithm
+// Every column (or row, for steep lines) the line crosses gets the two pixels
+// straddling it, each one blended in proportion to how close to it the line
+// passes; the two end points are weighted by how much of their pixel the line
+// actually covers
+// Unlike Draw, the end points are not rounded, and the parts of the line
+// outside the image are simply left out
+// NOTE: the given color code has to have been proviously added
+func (l *Line) DrawAntialiased(c BlendCanvas, color string) error {
+	x0, y0, x1, y1 := l.A.X, l.A.Y, l.B.X, l.B.Y
+
+	// walk along the axis the line moves the most along, and from left to
+	// right (or bottom to top)
+	steep := math.Abs(y1-y0) > math.Abs(x1-x0)
+	if steep {
+		x0, y0 = y0, x0
+		x1, y1 = y1, x1
+	}
+	if x0 > x1 {
+		x0, x1 = x1, x0
+		y0, y1 = y1, y0
+	}
+
+	// pixel paints the pixel at the given position along the walk
+	pixel := func(x, y int, alpha float64) error {
+		if steep {
+			return blendPlot(c, y, x, color, alpha)
+		}
+		return blendPlot(c, x, y, color, alpha)
+	}
+
+	gradient := 1.0
+	if dx := x1 - x0; dx != 0 {
+		gradient = (y1 - y0) / dx
+	}
+
+	// endpoint handles either end of the line, returning the column it falls
+	// on and the height of the line in it
+	endpoint := func(x, y float64, start bool) (int, float64, error) {
+		xend := math.Floor(x + .5)
+		yend := y + gradient*(xend-x)
+
+		// how much of the end pixel's width the line covers
+		gap := 1 - fpart(x+.5)
+		if !start {
+			gap = fpart(x + .5)
+		}
+
+		px, py := int(xend), int(math.Floor(yend))
+		if err := pixel(px, py, (1-fpart(yend))*gap); err != nil {
+			return 0, 0, err
+		}
+		if err := pixel(px, py+1, fpart(yend)*gap); err != nil {
+			return 0, 0, err
+		}
+		return px, yend, nil
+	}
+
+	// pixel centers lie on integer coordinates, so the two pixels straddling
+	// the line in each column are those its height floors and ceils to
+	first, yfirst, err := endpoint(x0, y0, true)
+	if err != nil {
+		return err
+	}
+	last, _, err := endpoint(x1, y1, false)
+	if err != nil || last == first {
+		return err
+	}
+
+	y := yfirst + gradient
+	for x := first + 1; x < last; x++ {
+		if err := pixel(x, int(math.Floor(y)), 1-fpart(y)); err != nil {
+			return err
+		}
+		if err := pixel(x, int(math.Floor(y))+1, fpart(y)); err != nil {
+			return err
+		}
+		y += gradient
+	}
+
+	return nil
+}
+
+// DrawAntialiased takes a BlendCanvas as parameter and proceeds to draw all
+// the segments of the Polyline on it anti-aliased, just like
+// Line.DrawAntialiased
+// Thick Polylines have their outline filled and Polylines dashed by pixels
+// are drawn just as Draw does
+// NOTE: the given color code has to have been proviously added
+func (pl *Polyline) DrawAntialiased(c BlendCanvas, color string) error {
+	if pl.Stroke.IsThick() || (pl.Stroke.IsDashed() && pl.Stroke.DashMeasure == DashByPixels) {
+		return pl.Draw(c, color)
+	}
+
+	for _, dash := range pl.dashes() {
+		for _, line := range dash.Lines() {
+			if err := line.DrawAntialiased(c, color); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package objects
+
+import (
+	"fmt"
+	"math"
+)
+
+// Arc is a part of the outline of an Ellipse, going counter-clockwise from
+// the parameter T0 to the parameter T1 (the angles, in radians, on the
+// circle the ellipse is a stretch of)
+// T1 is never less than T0, nor more than a whole turn beyond it
+type Arc struct {
+	Ellipse *Ellipse
+	T0, T1  float64
+}
+
+// String satisfies fmt.Stringer.
+func (a *Arc) String() string {
+	return fmt.Sprintf("<arc %s %g° - %g°>", a.Ellipse, a.T0*180/math.Pi, a.T1*180/math.Pi)
+}
+
+// NewArc returns a newly generated Arc structure, swept counter-clockwise
+// from t0 to t1 (brought within a whole turn beyond t0)
+func NewArc(e *Ellipse, t0, t1 float64) *Arc {
+	if t1-t0 > 2*math.Pi {
+		t1 = t0 + 2*math.Pi
+	}
+	for t1 < t0 {
+		t1 += 2 * math.Pi
+	}
+	return &Arc{Ellipse: e, T0: t0, T1: t1}
+}
+
+// Start returns the point the Arc starts at
+func (a *Arc) Start() *Point {
+	return a.Ellipse.pointAt(a.T0)
+}
+
+// End returns the point the Arc ends at
+func (a *Arc) End() *Point {
+	return a.Ellipse.pointAt(a.T1)
+}
+
+// IsFull returns true if the Arc goes all around its Ellipse
+func (a *Arc) IsFull() bool {
+	return a.T1-a.T0 >= 2*math.Pi
+}
+
+// Points returns the points of a polyline approximating the Arc from its
+// start to its end, straying at most flat from it, just like Ellipse.Outline
+func (a *Arc) Points(flat float64) []*Point {
+	r := math.Max(a.Ellipse.RX, a.Ellipse.RY)
+	sweep := a.T1 - a.T0
+
+	n := 1
+	if r > flat {
+		n = int(math.Ceil(sweep / (2 * math.Acos(1-flat/r))))
+	}
+	if n < 1 {
+		n = 1
+	}
+
+	points := make([]*Point, n+1)
+	for i := range points {
+		points[i] = a.Ellipse.pointAt(a.T0 + sweep*float64(i)/float64(n))
+	}
+	return points
+}
+
+// Polyline returns the polyline approximating the Arc to within clipFlatness,
+// as Points does
+func (a *Arc) Polyline() *Polyline {
+	return NewPolyline(a.Points(clipFlatness)...)
+}
+
+// contains returns true if the given parameter lies within the Arc, going
+// round the ellipse as many times as needed
+func (a *Arc) contains(t float64) bool {
+	return a.T0+math.Mod(math.Mod(t-a.T0, 2*math.Pi)+2*math.Pi, 2*math.Pi) <= a.T1
+}
+
+// Bounds returns the smallest rectangle containing the Arc: that of its ends
+// and of the points of the ellipse furthest left, right, down and up which
+// it goes through
+func (a *Arc) Bounds() *Rect {
+	e := a.Ellipse
+	sin, cos := math.Sincos(e.Angle)
+
+	// the parameters where the ellipse's outline is vertical and horizontal
+	tx := math.Atan2(-e.RY*sin, e.RX*cos)
+	ty := math.Atan2(e.RY*cos, e.RX*sin)
+
+	points := []*Point{a.Start(), a.End()}
+	for _, t := range []float64{tx, tx + math.Pi, ty, ty + math.Pi} {
+		if a.contains(t) {
+			points = append(points, e.pointAt(t))
+		}
+	}
+	return pointsBounds(points...)
+}
+
+// paramOf returns the parameter of the given point of the ellipse's outline
+func (e *Ellipse) paramOf(p *Point) float64 {
+	sin, cos := math.Sincos(e.Angle)
+	dx, dy := p.X-e.Center.X, p.Y-e.Center.Y
+
+	// back into the ellipse's own axes, then onto its circle
+	u := dx*cos + dy*sin
+	v := -dx*sin + dy*cos
+	return math.Atan2(v*e.RX, u*e.RY)
+}
+
+// Transform returns the Arc of the ellipse the given (affine) transformation
+// maps this one's to, between the images of its ends
+// Transformations which mirror the Arc reverse its direction, which is kept
+// counter-clockwise by swapping its ends
+func (a *Arc) Transform(t Transformer) Shape {
+	e := a.Ellipse.Transform(t).(*Ellipse)
+	if a.IsFull() {
+		return NewArc(e, 0, 2*math.Pi)
+	}
+
+	start := e.paramOf(t.TransformPoint(a.Start()))
+	end := e.paramOf(t.TransformPoint(a.End()))
+
+	o := t.TransformPoint(NewPoint(0, 0))
+	u := t.TransformPoint(NewPoint(1, 0))
+	v := t.TransformPoint(NewPoint(0, 1))
+	if (u.X-o.X)*(v.Y-o.Y)-(u.Y-o.Y)*(v.X-o.X) < 0 {
+		start, end = end, start
+	}
+	return NewArc(e, start, end)
+}
+
+// Clip returns the parts of the Arc visible through the given Clipper
+// CurveClippers clip it exactly, into Arcs; with any other Clipper, the Arc is
+// approximated by a Polyline which is clipped in turn
+func (a *Arc) Clip(c Clipper) ([]Shape, error) {
+	if !c.Bounds().Intersects(a.Bounds()) {
+		return nil, nil
+	}
+
+	if cc, ok := c.(CurveClipper); ok {
+		arcs, err := cc.ClipArc(a)
+		if err != nil {
+			return nil, err
+		}
+
+		shapes := make([]Shape, len(arcs))
+		for i, arc := range arcs {
+			shapes[i] = arc
+		}
+		return shapes, nil
+	}
+	return a.Polyline().Clip(c)
+}
+
+// Draw takes a Canvas as parameter and proceeds to draw the Arc on it using
+// the color code provided, as the Polyline approximating it
+// NOTE: the given color code has to have been proviously added
+func (a *Arc) Draw(c Canvas, color string) error {
+	return a.Polyline().Draw(c, color)
+}
+
+// DrawAntialiased draws the Arc just like Draw does, but anti-aliased
+func (a *Arc) DrawAntialiased(c BlendCanvas, color string) error {
+	return a.Polyline().DrawAntialiased(c, color)
+}
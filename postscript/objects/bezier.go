@@ -0,0 +1,187 @@
+package objects
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxBezierDepth caps the recursion depth of the flattening of Bézier curves,
+// so that degenerate curves can never recurse indefinitely
+const maxBezierDepth = 16
+
+// Bezier is a cubic Bézier curve, going from P0 to P3 and pulled towards P1
+// and P2 on the way, as postscript's curveto draws
+type Bezier struct {
+	P0, P1, P2, P3 *Point
+}
+
+// String satisfies fmt.Stringer.
+func (b *Bezier) String() string {
+	return fmt.Sprintf("<bezier %s %s %s %s>", b.P0, b.P1, b.P2, b.P3)
+}
+
+// NewBezier returns a newly generated Bezier structure
+func NewBezier(p0, p1, p2, p3 *Point) *Bezier {
+	return &Bezier{P0: p0, P1: p1, P2: p2, P3: p3}
+}
+
+// lerp returns the point at t of the way from a to b
+func lerp(a, b *Point, t float64) *Point {
+	return NewPoint(a.X+(b.X-a.X)*t, a.Y+(b.Y-a.Y)*t)
+}
+
+// PointAt returns the point of the curve at the given parameter, the curve
+// going from P0 at 0 to P3 at 1
+func (b *Bezier) PointAt(t float64) *Point {
+	_, right := b.Split(t)
+	return right.P0
+}
+
+// Split splits the curve at the given parameter into the two curves before
+// and after it, with de Casteljau's algorithm
+func (b *Bezier) Split(t float64) (*Bezier, *Bezier) {
+	p01 := lerp(b.P0, b.P1, t)
+	p12 := lerp(b.P1, b.P2, t)
+	p23 := lerp(b.P2, b.P3, t)
+	p012 := lerp(p01, p12, t)
+	p123 := lerp(p12, p23, t)
+	mid := lerp(p012, p123, t)
+
+	return NewBezier(b.P0, p01, p012, mid), NewBezier(mid, p123, p23, b.P3)
+}
+
+// Sub returns the part of the curve between the parameters t0 and t1
+func (b *Bezier) Sub(t0, t1 float64) *Bezier {
+	if t1 <= 0 {
+		return NewBezier(b.P0, b.P0, b.P0, b.P0)
+	}
+	left, _ := b.Split(t1)
+	_, res := left.Split(t0 / t1)
+	return res
+}
+
+// distanceToChord returns the distance between p and the line through a and b
+// If a and b coincide, it is simply the distance between p and a
+func distanceToChord(p, a, b *Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return math.Hypot(p.X-a.X, p.Y-a.Y)
+	}
+	return math.Abs(dx*(a.Y-p.Y)-dy*(a.X-p.X)) / length
+}
+
+// flatten appends the points of a polyline approximating the curve, except
+// for its starting point, to dst
+// The curve is split in halves until both inner control points lie within
+// flat of the chord, just as the postscript interpreter flattens curveto
+func (b *Bezier) flatten(dst []*Point, flat float64, depth int) []*Point {
+	if depth >= maxBezierDepth ||
+		(distanceToChord(b.P1, b.P0, b.P3) <= flat && distanceToChord(b.P2, b.P0, b.P3) <= flat) {
+		return append(dst, b.P3)
+	}
+
+	left, right := b.Split(0.5)
+	dst = left.flatten(dst, flat, depth+1)
+	return right.flatten(dst, flat, depth+1)
+}
+
+// Points returns the points of a polyline approximating the curve from its
+// start to its end, straying at most flat from it
+func (b *Bezier) Points(flat float64) []*Point {
+	return b.flatten([]*Point{b.P0}, flat, 0)
+}
+
+// Polyline returns the polyline approximating the curve to within
+// clipFlatness, as Points does
+func (b *Bezier) Polyline() *Polyline {
+	return NewPolyline(b.Points(clipFlatness)...)
+}
+
+// extrema returns the parameters within (0, 1) at which a cubic Bézier curve
+// with the given coordinates along one axis turns back along it, where its
+// derivative is 0
+func extrema(p0, p1, p2, p3 float64) []float64 {
+	// the derivative is the quadratic a t² + b t + c
+	a := 3 * (-p0 + 3*p1 - 3*p2 + p3)
+	b := 6 * (p0 - 2*p1 + p2)
+	c := 3 * (p1 - p0)
+
+	roots := []float64{}
+	switch {
+	case math.Abs(a) < 1e-12:
+		if b != 0 {
+			roots = append(roots, -c/b)
+		}
+	default:
+		disc := b*b - 4*a*c
+		if disc >= 0 {
+			sq := math.Sqrt(disc)
+			roots = append(roots, (-b+sq)/(2*a), (-b-sq)/(2*a))
+		}
+	}
+
+	res := []float64{}
+	for _, t := range roots {
+		if t > 0 && t < 1 {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// Bounds returns the smallest rectangle containing the curve: that of its
+// ends and of the points where it turns back horizontally or vertically
+func (b *Bezier) Bounds() *Rect {
+	points := []*Point{b.P0, b.P3}
+	ts := append(extrema(b.P0.X, b.P1.X, b.P2.X, b.P3.X), extrema(b.P0.Y, b.P1.Y, b.P2.Y, b.P3.Y)...)
+	for _, t := range ts {
+		points = append(points, b.PointAt(t))
+	}
+	return pointsBounds(points...)
+}
+
+// Transform returns the curve the given (affine) transformation maps this one
+// to, which is that of the images of its control points
+func (b *Bezier) Transform(t Transformer) Shape {
+	return NewBezier(
+		t.TransformPoint(b.P0), t.TransformPoint(b.P1),
+		t.TransformPoint(b.P2), t.TransformPoint(b.P3),
+	)
+}
+
+// Clip returns the parts of the curve visible through the given Clipper
+// CurveClippers clip it exactly, into Beziers; with any other Clipper, the
+// curve is approximated by a Polyline which is clipped in turn
+func (b *Bezier) Clip(c Clipper) ([]Shape, error) {
+	if !c.Bounds().Intersects(b.Bounds()) {
+		return nil, nil
+	}
+
+	if cc, ok := c.(CurveClipper); ok {
+		curves, err := cc.ClipBezier(b)
+		if err != nil {
+			return nil, err
+		}
+
+		shapes := make([]Shape, len(curves))
+		for i, curve := range curves {
+			shapes[i] = curve
+		}
+		return shapes, nil
+	}
+	return b.Polyline().Clip(c)
+}
+
+// Draw takes a Canvas as parameter and proceeds to draw the curve on it using
+// the color code provided, as the Polyline approximating it
+// NOTE: the given color code has to have been proviously added
+func (b *Bezier) Draw(c Canvas, color string) error {
+	return b.Polyline().Draw(c, color)
+}
+
+// DrawAntialiased draws the curve just like Draw does, but anti-aliased
+func (b *Bezier) DrawAntialiased(c BlendCanvas, color string) error {
+	return b.Polyline().DrawAntialiased(c, color)
+}
@@ -0,0 +1,135 @@
+package objects
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"../../xpm"
+)
+
+// Canvas is implemented by everything shapes can be rasterized onto: a grid
+// of pixels addressed in the usual right-handed cartesian system, painted in
+// colors referred to by their character combinations
+// Both xpm.XPM and RGBACanvas are Canvases
+type Canvas interface {
+	// Width and Height return the dimensions of the Canvas in pixels
+	Width() int
+	Height() int
+
+	// SetPixelCartesian sets the pixel at the given 0-ordered cartesian
+	// coordinates to the color with the given character combination
+	// It returns an error if the pixel is out of range or if the color has
+	// not been defined
+	SetPixelCartesian(x, y int, cc string) error
+}
+
+// RGBACanvas adapts an image.RGBA into a Canvas, with colors defined by
+// their character combinations just like with XPMs
+type RGBACanvas struct {
+	Image *image.RGBA
+
+	colors map[string]color.RGBA
+}
+
+// NewRGBACanvas returns a new RGBACanvas drawing onto the given image
+func NewRGBACanvas(img *image.RGBA) *RGBACanvas {
+	return &RGBACanvas{
+		Image:  img,
+		colors: make(map[string]color.RGBA),
+	}
+}
+
+// AddColor defines a fully opaque color with the given red, green and blue
+// values, as well as the character combination it is referred to by
+// If a color with the given character combination has already been defined,
+// the function will return an error
+func (c *RGBACanvas) AddColor(r, g, b byte, cc string) error {
+	if _, ok := c.colors[cc]; ok {
+		return fmt.Errorf("Color %q already defined!", cc)
+	}
+
+	c.colors[cc] = color.RGBA{r, g, b, 255}
+	return nil
+}
+
+// Width returns the width of the image in pixels
+func (c *RGBACanvas) Width() int {
+	return c.Image.Bounds().Dx()
+}
+
+// Height returns the height of the image in pixels
+func (c *RGBACanvas) Height() int {
+	return c.Image.Bounds().Dy()
+}
+
+// SetPixelCartesian sets a pixel at the given 0-ordered right-handed cartesian
+// coordinates x and y and with the given color character combination
+// Returns an error if any of the given coordinates is out of range or if
+// the color character combination has not been defined
+func (c *RGBACanvas) SetPixelCartesian(x, y int, cc string) error {
+	if x < 0 || x >= c.Width() {
+		return fmt.Errorf("Invalid x=%d", x)
+	}
+	if y < 0 || y >= c.Height() {
+		return fmt.Errorf("Invalid y=%d", y)
+	}
+
+	col, ok := c.colors[cc]
+	if !ok {
+		return fmt.Errorf("Nonexistent color combination %s in this canvas", cc)
+	}
+
+	// images have their origin in the upper left corner
+	b := c.Image.Bounds()
+	c.Image.SetRGBA(b.Min.X+x, b.Max.Y-1-y, col)
+	return nil
+}
+
+// BlendCanvas is implemented by the Canvases which can paint pixels partially,
+// blending colors with whatever the pixels already hold, as anti-aliased
+// drawing requires
+// Both xpm.XPM and RGBACanvas are BlendCanvases
+type BlendCanvas interface {
+	Canvas
+
+	// BlendPixelCartesian paints the pixel at the given 0-ordered cartesian
+	// coordinates with the color with the given character combination at
+	// the given coverage, from 0 (leaving the pixel untouched) to 1 (just
+	// like SetPixelCartesian)
+	// It returns an error if the pixel is out of range or if the color has
+	// not been defined
+	BlendPixelCartesian(x, y int, cc string, alpha float64) error
+}
+
+// BlendPixelCartesian paints a pixel at the given 0-ordered right-handed
+// cartesian coordinates x and y with the given color character combination at
+// the given coverage, blending it with the pixel's current color
+// Returns an error if any of the given coordinates is out of range or if
+// the color character combination has not been defined
+func (c *RGBACanvas) BlendPixelCartesian(x, y int, cc string, alpha float64) error {
+	if x < 0 || x >= c.Width() {
+		return fmt.Errorf("Invalid x=%d", x)
+	}
+	if y < 0 || y >= c.Height() {
+		return fmt.Errorf("Invalid y=%d", y)
+	}
+
+	col, ok := c.colors[cc]
+	if !ok {
+		return fmt.Errorf("Nonexistent color combination %s in this canvas", cc)
+	}
+	alpha = math.Max(0, math.Min(1, alpha))
+
+	b := c.Image.Bounds()
+	px, py := b.Min.X+x, b.Max.Y-1-y
+	old := c.Image.RGBAAt(px, py)
+	c.Image.SetRGBA(px, py, color.RGBA{
+		xpm.Blend(old.R, col.R, alpha),
+		xpm.Blend(old.G, col.G, alpha),
+		xpm.Blend(old.B, col.B, alpha),
+		xpm.Blend(old.A, col.A, alpha),
+	})
+	return nil
+}
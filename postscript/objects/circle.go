@@ -0,0 +1,123 @@
+package objects
+
+import (
+	"fmt"
+	"math"
+)
+
+// Circle is a circle given by its center and radius
+type Circle struct {
+	Center *Point
+	R      float64
+
+	// Filled makes Draw fill the circle instead of drawing its outline
+	Filled bool
+}
+
+// String satisfies fmt.Stringer.
+func (c *Circle) String() string {
+	return fmt.Sprintf("<circle %s %g>", c.Center, c.R)
+}
+
+// NewCircle returns a newly generated Circle structure
+func NewCircle(center *Point, r float64) *Circle {
+	return &Circle{Center: center, R: r}
+}
+
+// Ellipse returns the circle as an Ellipse with equal radii
+func (c *Circle) Ellipse() *Ellipse {
+	e := NewEllipse(c.Center, c.R, c.R, 0)
+	e.Filled = c.Filled
+	return e
+}
+
+// Bounds returns the smallest rectangle containing the Circle
+func (c *Circle) Bounds() *Rect {
+	return NewRect(c.Center.X-c.R, c.Center.Y-c.R, c.Center.X+c.R, c.Center.Y+c.R)
+}
+
+// Transform returns the shape which the given (affine) transformation maps
+// the circle to: another Circle if the transformation preserves its
+// roundness, or an Ellipse otherwise
+func (c *Circle) Transform(t Transformer) Shape {
+	e := c.Ellipse().Transform(t).(*Ellipse)
+	if math.Abs(e.RX-e.RY) > 1e-9*math.Max(1, e.RX) {
+		return e
+	}
+
+	res := NewCircle(e.Center, e.RX)
+	res.Filled = c.Filled
+	return res
+}
+
+// Clip returns the parts of the Circle visible through the given Clipper, just
+// like Ellipse.Clip does, the Circle itself being kept if it is entirely
+// visible
+func (c *Circle) Clip(cl Clipper) ([]Shape, error) {
+	parts, err := c.Ellipse().Clip(cl)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 1 {
+		if _, ok := parts[0].(*Ellipse); ok {
+			return []Shape{c}, nil
+		}
+	}
+	return parts, nil
+}
+
+// Draw takes a Canvas as parameter and proceeds to draw the outline of the
+// circle (or to fill it) on it using the color code provided, using the
+// midpoint circle algorithm
+// Only one octant of the circle is actually walked; the other seven are
+// mirror images of it
+// Like Polygon.Draw, the parts of the circle outside the image are simply
+// left out
+// NOTE: the given color code has to have been proviously added
+func (c *Circle) Draw(cv Canvas, color string) error {
+	center := c.Center.Round()
+	cx, cy := center.X, center.Y
+
+	// plot8 plots the eight symmetrical points of the current one, or the
+	// four spans between them when filling
+	plot8 := func(x, y int) error {
+		if c.Filled {
+			for _, s := range [][2]int{{x, y}, {x, -y}, {y, x}, {y, -x}} {
+				if err := span(cv, cx-s[0], cx+s[0], cy+s[1], color); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		for _, p := range [][2]int{
+			{x, y}, {-x, y}, {x, -y}, {-x, -y},
+			{y, x}, {-y, x}, {y, -x}, {-y, -x},
+		} {
+			if err := plot(cv, cx+p[0], cy+p[1], color); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// walk the octant going up from the rightmost point, stepping left
+	// whenever the midpoint between the two candidates lies outside
+	x, y := round(c.R), 0
+	d := 1 - x
+	for x >= y {
+		if err := plot8(x, y); err != nil {
+			return err
+		}
+
+		y++
+		if d < 0 {
+			d = d + 2*y + 1
+		} else {
+			x--
+			d = d + 2*(y-x) + 1
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package objects
+
+import (
+	"fmt"
+	"math"
+)
+
+// DashMeasure determines how the lengths in a dash pattern are measured along
+// the path being dashed
+type DashMeasure int
+
+const (
+	// DashByLength measures dashes by their length along the path, as
+	// postscript does
+	DashByLength DashMeasure = iota
+
+	// DashByPixels measures dashes in pixels, so that a dash of 3 is exactly
+	// three pixels long however steep the line is
+	DashByPixels
+)
+
+// String satisfies fmt.Stringer.
+func (m DashMeasure) String() string {
+	if m == DashByPixels {
+		return "pixels"
+	}
+	return "length"
+}
+
+// SetDash sets the dash pattern of the Stroke, as postscript's setdash does
+// The pattern alternates the lengths of the dashes and of the gaps between
+// them, starting with a dash, and is repeated all along the path; the offset
+// is how far into the pattern the path starts
+// An empty pattern makes the stroke solid
+// Returns an error if any of the lengths is negative or if all of them are 0
+func (s *Stroke) SetDash(dash []float64, offset float64) error {
+	total := 0.0
+	for _, d := range dash {
+		if d < 0 {
+			return fmt.Errorf("Invalid dash length %g", d)
+		}
+		total += d
+	}
+	if len(dash) > 0 && total == 0 {
+		return fmt.Errorf("Invalid dash pattern of zero length")
+	}
+
+	s.Dash = append([]float64{}, dash...)
+	s.DashOffset = offset
+	return nil
+}
+
+// IsDashed returns true if strokes of this kind have a dash pattern
+func (s *Stroke) IsDashed() bool {
+	return s != nil && len(s.Dash) > 0
+}
+
+// dashPattern returns the dash pattern with an even number of entries,
+// an odd one being gone through twice to get back to a dash, along with its
+// total length
+func (s *Stroke) dashPattern() ([]float64, float64) {
+	pattern := s.Dash
+	if len(pattern)%2 == 1 {
+		pattern = append(append([]float64{}, pattern...), pattern...)
+	}
+
+	total := 0.0
+	for _, d := range pattern {
+		total += d
+	}
+	return pattern, total
+}
+
+// dashAt returns the index of the entry of the pattern the given distance
+// along the path falls into, and how much of that entry is left from there
+// Entries of zero length are only ever fallen into right where they are, for
+// dashes of zero length to still be drawn as points (showing their caps)
+func (s *Stroke) dashAt(t float64) (int, float64) {
+	pattern, total := s.dashPattern()
+
+	t = math.Mod(t+s.DashOffset, total)
+	if t < 0 {
+		t += total
+	}
+
+	i := 0
+	for t > pattern[i] || (t == pattern[i] && pattern[i] > 0) {
+		t -= pattern[i]
+		i = (i + 1) % len(pattern)
+	}
+	return i, pattern[i] - t
+}
+
+// dashLength returns the distance between a and b as dashes measure it
+func (s *Stroke) dashLength(a, b *Point) float64 {
+	if s.DashMeasure == DashByPixels {
+		return math.Max(math.Abs(b.X-a.X), math.Abs(b.Y-a.Y))
+	}
+	return math.Hypot(b.X-a.X, b.Y-a.Y)
+}
+
+// Dashes splits the polyline going through the given points (and back to
+// the first one if closed) into the dashes of the Stroke's pattern, returning
+// the points of each one of them
+// The pattern carries on from one segment to the next, so that dashes go
+// round corners; dashes of zero length are returned as a point given twice,
+// for the caps of thick strokes to show there
+// If the Stroke has no dash pattern, the whole polyline is returned
+func (s *Stroke) Dashes(points []*Point, closed bool) [][]*Point {
+	if !s.IsDashed() || len(points) == 0 {
+		return [][]*Point{points}
+	}
+
+	pts := points
+	if closed && len(pts) > 2 {
+		pts = append(pts[:len(pts):len(pts)], pts[0])
+	}
+
+	pattern, _ := s.dashPattern()
+	i, left := s.dashAt(0)
+
+	dashes := [][]*Point{}
+	var cur []*Point
+	if i%2 == 0 {
+		cur = []*Point{pts[0]}
+	}
+
+	for k := 1; k < len(pts); k++ {
+		a, b := pts[k-1], pts[k]
+		length := s.dashLength(a, b)
+
+		// every dash or gap ending within the segment
+		pos := 0.0
+		for length-pos > left {
+			pos += left
+			p := NewPoint(a.X+(b.X-a.X)*pos/length, a.Y+(b.Y-a.Y)*pos/length)
+			if cur != nil {
+				dashes = append(dashes, append(cur, p))
+				cur = nil
+			} else {
+				cur = []*Point{p}
+			}
+
+			i = (i + 1) % len(pattern)
+			left = pattern[i]
+		}
+
+		left -= length - pos
+		if cur != nil {
+			cur = append(cur, b)
+		}
+	}
+
+	if len(cur) > 1 {
+		dashes = append(dashes, cur)
+	}
+
+	// a gap ending right at the end of the path, followed by a dash of zero
+	// length, still leaves that dash as a point
+	last := pts[len(pts)-1]
+	if cur == nil && left == 0 && pattern[(i+1)%len(pattern)] == 0 {
+		dashes = append(dashes, []*Point{last, last})
+	}
+	return dashes
+}
+
+// dashOn returns true if the pixel the given number of pixels along the path
+// falls on a dash rather than a gap, as measured by its center
+func (s *Stroke) dashOn(n int) bool {
+	i, _ := s.dashAt(float64(n) + .5)
+	return i%2 == 0
+}
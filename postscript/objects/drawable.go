@@ -0,0 +1,9 @@
+package objects
+
+// Drawable is implemented by all the objects which know how to draw
+// themselves onto a Canvas
+type Drawable interface {
+	// Draw draws the object on the given Canvas with the given color code
+	// NOTE: the given color code has to have been proviously added
+	Draw(c Canvas, color string) error
+}
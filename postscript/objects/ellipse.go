@@ -0,0 +1,419 @@
+package objects
+
+import (
+	"fmt"
+	"math"
+)
+
+// clipFlatness is the maximum distance (in pixels) between a curved shape and
+// the Lines or Polygon it is approximated by when it has to be clipped
+const clipFlatness = 0.25
+
+// Ellipse is an ellipse given by its center, the radii along its own axes and
+// the angle by which those axes are rotated
+type Ellipse struct {
+	Center *Point
+
+	// RX and RY are the radii along the ellipse's X and Y axes
+	RX, RY float64
+
+	// Angle is the counter-clockwise rotation of the ellipse's X axis from
+	// the horizontal, in radians
+	Angle float64
+
+	// Filled makes Draw fill the ellipse instead of drawing its outline
+	Filled bool
+}
+
+// String satisfies fmt.Stringer.
+func (e *Ellipse) String() string {
+	return fmt.Sprintf("<ellipse %s %g %g %g°>", e.Center, e.RX, e.RY, e.Angle*180/math.Pi)
+}
+
+// NewEllipse returns a newly generated Ellipse structure
+func NewEllipse(center *Point, rx, ry, angle float64) *Ellipse {
+	return &Ellipse{Center: center, RX: rx, RY: ry, Angle: angle}
+}
+
+// isAxisAligned returns true if the ellipse's axes are horizontal and
+// vertical, in which case it returns its horizontal and vertical radii too
+func (e *Ellipse) isAxisAligned() (aligned bool, rx, ry float64) {
+	const eps = 1e-9
+
+	quarters := e.Angle / (math.Pi / 2)
+	n := math.Floor(quarters + 0.5)
+	if math.Abs(quarters-n) > eps {
+		return false, 0, 0
+	}
+
+	if int(n)%2 == 0 {
+		return true, e.RX, e.RY
+	}
+	return true, e.RY, e.RX
+}
+
+// extents returns the horizontal and vertical distances from the center of
+// the ellipse to the sides of its bounding rectangle
+func (e *Ellipse) extents() (ex, ey float64) {
+	sin, cos := math.Sincos(e.Angle)
+	ex = math.Hypot(e.RX*cos, e.RY*sin)
+	ey = math.Hypot(e.RX*sin, e.RY*cos)
+	return
+}
+
+// Bounds returns the smallest rectangle containing the Ellipse
+func (e *Ellipse) Bounds() *Rect {
+	ex, ey := e.extents()
+	return NewRect(e.Center.X-ex, e.Center.Y-ey, e.Center.X+ex, e.Center.Y+ey)
+}
+
+// pointAt returns the point of the ellipse's outline at the given parameter
+// (the angle, in radians, on the circle the ellipse is a stretch of)
+func (e *Ellipse) pointAt(t float64) *Point {
+	sin, cos := math.Sincos(e.Angle)
+	u, v := e.RX*math.Cos(t), e.RY*math.Sin(t)
+	return NewPoint(e.Center.X+u*cos-v*sin, e.Center.Y+u*sin+v*cos)
+}
+
+// Outline returns the vertices of a polygon approximating the outline of the
+// ellipse counter-clockwise, straying at most flat from it
+func (e *Ellipse) Outline(flat float64) []*Point {
+	// segments spanning an angle of step around a circle of radius r stray
+	// r * (1 - cos(step / 2)) from it at most
+	r := math.Max(e.RX, e.RY)
+	n := 4
+	if r > flat {
+		n = int(math.Ceil(2 * math.Pi / (2 * math.Acos(1-flat/r))))
+		if n < 4 {
+			n = 4
+		}
+	}
+
+	points := make([]*Point, n)
+	for i := range points {
+		points[i] = e.pointAt(2 * math.Pi * float64(i) / float64(n))
+	}
+	return points
+}
+
+// Transform returns the ellipse which the given (affine) transformation maps
+// this one to
+// The images of the ends of two perpendicular radii are conjugate radii of
+// the resulting ellipse, whose axes are then found as those of the matrix
+// they make up
+func (e *Ellipse) Transform(t Transformer) Shape {
+	c := t.TransformPoint(e.Center)
+	p := t.TransformPoint(e.pointAt(0))
+	q := t.TransformPoint(e.pointAt(math.Pi / 2))
+
+	// M = [u v] maps the unit circle onto the new ellipse; the radii are the
+	// square roots of the eigenvalues of M * M^T and the angle is that of the
+	// eigenvector of the largest one
+	ux, uy := p.X-c.X, p.Y-c.Y
+	vx, vy := q.X-c.X, q.Y-c.Y
+
+	a := ux*ux + vx*vx
+	b := ux*uy + vx*vy
+	d := uy*uy + vy*vy
+
+	mean := (a + d) / 2
+	dev := math.Hypot((a-d)/2, b)
+
+	res := NewEllipse(
+		c,
+		math.Sqrt(mean+dev),
+		math.Sqrt(math.Max(0, mean-dev)),
+		math.Atan2(2*b, a-d)/2,
+	)
+	res.Filled = e.Filled
+	return res
+}
+
+// Clip returns the parts of the Ellipse visible through the given Clipper
+// Ellipses lying entirely outside of the Clipper's bounds are left out
+// The outline of any other one is clipped exactly into Arcs if the Clipper is
+// a CurveClipper; otherwise it is approximated by Lines which are clipped in
+// turn. Either way, the whole Ellipse is kept if all of it is visible
+// Otherwise, the visible parts of the outline are returned, or those of a
+// Polygon approximating the Ellipse if it is filled
+func (e *Ellipse) Clip(c Clipper) ([]Shape, error) {
+	if !c.Bounds().Intersects(e.Bounds()) {
+		return nil, nil
+	}
+
+	if cc, ok := c.(CurveClipper); ok && !e.Filled {
+		arcs, err := cc.ClipArc(NewArc(e, 0, 2*math.Pi))
+		if err != nil {
+			return nil, err
+		}
+		if len(arcs) == 1 && arcs[0].IsFull() {
+			return []Shape{e}, nil
+		}
+
+		shapes := make([]Shape, len(arcs))
+		for i, arc := range arcs {
+			shapes[i] = arc
+		}
+		return shapes, nil
+	}
+
+	outline := e.Outline(clipFlatness)
+
+	whole := true
+	shapes := []Shape{}
+	for i := range outline {
+		line := NewLine(outline[i], outline[(i+1)%len(outline)])
+		parts, err := ClipSegments(c, line)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(parts) != 1 || !samePoint(parts[0].A, line.A) || !samePoint(parts[0].B, line.B) {
+			whole = false
+		}
+		for _, part := range parts {
+			shapes = append(shapes, part)
+		}
+	}
+
+	switch {
+	case whole:
+		return []Shape{e}, nil
+	case e.Filled:
+		return NewPolygon(NonZero, outline).Clip(c)
+	}
+	return shapes, nil
+}
+
+// plot sets the pixel at the given cartesian coordinates if it lies within the
+// Canvas, leaving out any pixel outside of it
+func plot(c Canvas, x, y int, color string) error {
+	if x < 0 || x >= c.Width() || y < 0 || y >= c.Height() {
+		return nil
+	}
+	return c.SetPixelCartesian(x, y, color)
+}
+
+// span sets all the pixels in the given row from x0 to x1, leaving out those
+// outside of the Canvas
+func span(c Canvas, x0, x1, y int, color string) error {
+	if y < 0 || y >= c.Height() {
+		return nil
+	}
+	if x0 < 0 {
+		x0 = 0
+	}
+	if x1 > c.Width()-1 {
+		x1 = c.Width() - 1
+	}
+
+	for x := x0; x <= x1; x++ {
+		if err := c.SetPixelCartesian(x, y, color); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Draw takes a Canvas as parameter and proceeds to draw the outline of the
+// ellipse (or to fill it) on it using the color code provided
+// Axis-aligned ellipses are rasterized with the midpoint ellipse algorithm,
+// rotated ones scanline by scanline
+// Like Polygon.Draw, the parts of the ellipse outside the image are simply
+// left out
+// NOTE: the given color code has to have been proviously added
+func (e *Ellipse) Draw(c Canvas, color string) error {
+	if aligned, rx, ry := e.isAxisAligned(); aligned {
+		return drawMidpointEllipse(c, e.Center.Round(), round(rx), round(ry), e.Filled, color)
+	}
+	return e.drawScanlines(c, color)
+}
+
+// drawMidpointEllipse draws the axis-aligned ellipse with the given center and
+// radii using the midpoint ellipse algorithm, filling it if so requested
+// The first quadrant is walked in two regions: the one where the outline is
+// closer to horizontal, where x always advances, and the one where it is
+// closer to vertical, where y always does
+func drawMidpointEllipse(c Canvas, center *IntPoint, rx, ry int, filled bool, color string) error {
+	// plot4 plots the four symmetrical points of the current one, or the
+	// two spans between them when filling
+	plot4 := func(x, y int) error {
+		if filled {
+			if err := span(c, center.X-x, center.X+x, center.Y+y, color); err != nil {
+				return err
+			}
+			return span(c, center.X-x, center.X+x, center.Y-y, color)
+		}
+
+		for _, p := range [][2]int{{x, y}, {-x, y}, {x, -y}, {-x, -y}} {
+			if err := plot(c, center.X+p[0], center.Y+p[1], color); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// a flat ellipse is but a horizontal segment
+	if ry == 0 {
+		return span(c, center.X-rx, center.X+rx, center.Y, color)
+	}
+
+	rx2, ry2 := float64(rx*rx), float64(ry*ry)
+	x, y := 0, ry
+	dx, dy := 0.0, 2*rx2*float64(y)
+
+	// region 1: the slope of the outline is less than 1 in magnitude
+	d1 := ry2 - rx2*float64(ry) + rx2/4
+	for dx < dy {
+		if err := plot4(x, y); err != nil {
+			return err
+		}
+
+		x++
+		dx = dx + 2*ry2
+		if d1 < 0 {
+			d1 = d1 + dx + ry2
+		} else {
+			y--
+			dy = dy - 2*rx2
+			d1 = d1 + dx - dy + ry2
+		}
+	}
+
+	// region 2: the slope of the outline is greater than 1 in magnitude
+	fx, fy := float64(x)+0.5, float64(y-1)
+	d2 := ry2*fx*fx + rx2*fy*fy - rx2*ry2
+	for y >= 0 {
+		if err := plot4(x, y); err != nil {
+			return err
+		}
+
+		y--
+		dy = dy - 2*rx2
+		if d2 > 0 {
+			d2 = d2 + rx2 - dy
+		} else {
+			x++
+			dx = dx + 2*ry2
+			d2 = d2 + dx - dy + rx2
+		}
+	}
+
+	return nil
+}
+
+// rowSpan returns the first and last pixels of the given row whose centers
+// lie within the ellipse; ok is false if the row misses the ellipse entirely
+func (e *Ellipse) rowSpan(y int) (x0, x1 int, ok bool) {
+	sin, cos := math.Sincos(e.Angle)
+	a2, b2 := e.RX*e.RX, e.RY*e.RY
+	dy := float64(y) - e.Center.Y
+
+	// the points of the row within the ellipse are the solutions of a
+	// quadratic inequality in their distance dx from the center:
+	// (dx*cos + dy*sin)^2 / a^2 + (dy*cos - dx*sin)^2 / b^2 <= 1
+	qa := cos*cos/a2 + sin*sin/b2
+	qb := 2 * dy * sin * cos * (1/a2 - 1/b2)
+	qc := dy*dy*(sin*sin/a2+cos*cos/b2) - 1
+
+	disc := qb*qb - 4*qa*qc
+	if disc < 0 {
+		return 0, 0, false
+	}
+
+	left := e.Center.X + (-qb-math.Sqrt(disc))/(2*qa)
+	right := e.Center.X + (-qb+math.Sqrt(disc))/(2*qa)
+	x0, x1 = int(math.Ceil(left)), int(math.Floor(right))
+
+	// rows crossing a thin ellipse between two pixel centers still get the
+	// pixel closest to the crossing so that the outline never breaks up
+	if x0 > x1 {
+		x0 = round((left + right) / 2)
+		x1 = x0
+	}
+
+	return x0, x1, true
+}
+
+// drawScanlines draws the outline of the ellipse (or fills it) row by row
+// The outline is made up of the pixels of each row's span which the spans of
+// the rows above and below do not cover
+func (e *Ellipse) drawScanlines(c Canvas, color string) error {
+	// degenerate ellipses are mere segments along their longer axis
+	if e.RX == 0 || e.RY == 0 {
+		if e.RX == 0 {
+			return NewLine(e.pointAt(math.Pi/2), e.pointAt(-math.Pi/2)).Draw(c, color)
+		}
+		return NewLine(e.pointAt(0), e.pointAt(math.Pi)).Draw(c, color)
+	}
+
+	_, ey := e.extents()
+	miny := int(math.Ceil(e.Center.Y - ey))
+	maxy := int(math.Floor(e.Center.Y + ey))
+
+	type row struct {
+		x0, x1 int
+		ok     bool
+	}
+	rowAt := func(y int) row {
+		x0, x1, ok := e.rowSpan(y)
+		return row{x0, x1, ok}
+	}
+
+	prev, cur := row{}, rowAt(miny)
+	for y := miny; y <= maxy; y++ {
+		next := row{}
+		if y < maxy {
+			next = rowAt(y + 1)
+		}
+		if !cur.ok {
+			prev, cur = cur, next
+			continue
+		}
+
+		if e.Filled || !prev.ok || !next.ok {
+			if err := span(c, cur.x0, cur.x1, y, color); err != nil {
+				return err
+			}
+			prev, cur = cur, next
+			continue
+		}
+
+		// the pixels left uncovered by the neighbouring rows on the left
+		// and on the right of the span
+		left := maxInt(prev.x0, next.x0) - 1
+		right := minInt(prev.x1, next.x1) + 1
+		if left >= right-1 {
+			if err := span(c, cur.x0, cur.x1, y, color); err != nil {
+				return err
+			}
+		} else {
+			if err := span(c, cur.x0, maxInt(cur.x0, left), y, color); err != nil {
+				return err
+			}
+			if err := span(c, minInt(cur.x1, right), cur.x1, y, color); err != nil {
+				return err
+			}
+		}
+
+		prev, cur = cur, next
+	}
+
+	return nil
+}
+
+// minInt returns the smaller of two ints
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxInt returns the larger of two ints
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -2,8 +2,6 @@ package objects
 
 import (
 	"fmt"
-
-	"../../xpm"
 )
 
 // Line is the basic structure of a postscript line definition
@@ -21,18 +19,73 @@ func NewLine(a, b *Point) *Line {
 	return &Line{A: a, B: b}
 }
 
-// Draw takes an XPM image structure as parameter and proceeds to draw this
+// Bounds returns the smallest rectangle containing the Line
+func (l *Line) Bounds() *Rect {
+	return pointsBounds(l.A, l.B)
+}
+
+// Transform returns the Line between the images of its end points
+func (l *Line) Transform(t Transformer) Shape {
+	return NewLine(t.TransformPoint(l.A), t.TransformPoint(l.B))
+}
+
+// Clip returns the parts of the Line visible through the given Clipper
+func (l *Line) Clip(c Clipper) ([]Shape, error) {
+	parts, err := ClipSegments(c, l)
+	if err != nil {
+		return nil, err
+	}
+
+	shapes := make([]Shape, len(parts))
+	for i, part := range parts {
+		shapes[i] = part
+	}
+	return shapes, nil
+}
+
+// IntLine is a line between two points with integer coordinates
+type IntLine struct {
+	A, B *IntPoint
+}
+
+// String satisfies fmt.Stringer.
+func (l *IntLine) String() string {
+	return fmt.Sprintf("[%s - %s]", l.A, l.B)
+}
+
+// NewIntLine returns a newly generated IntLine structure
+func NewIntLine(a, b *IntPoint) *IntLine {
+	return &IntLine{A: a, B: b}
+}
+
+// Line converts the IntLine to a Line
+func (l *IntLine) Line() *Line {
+	return NewLine(l.A.Point(), l.B.Point())
+}
+
+// Draw takes a Canvas as parameter and proceeds to draw this
 // line on it using the color code provided using Bresenham's algorithm
-// The line is drawn with respect to the usual right-handed cartesian system
+// The line is drawn with respect to the usual right-handed cartesian system,
+// between the pixels its two end points round to
 // If the two points of the line are outside the image, an error is returned
 // NOTE: the given color code has to have been proviously added
-func (l *Line) Draw(xpm *xpm.XPM, color string) error {
-	// convert int coordinates to regular ints
-	// for consistent operations below
-	x0 := int(l.A.X)
-	x1 := int(l.B.X)
-	y0 := int(l.A.Y)
-	y1 := int(l.B.Y)
+func (l *Line) Draw(c Canvas, color string) error {
+	return l.pixels(func(x, y int) error {
+		return c.SetPixelCartesian(x, y, color)
+	})
+}
+
+// pixels walks all the pixels Bresenham's algorithm picks for the line, from
+// the one its first end point rounds to to the one its second end point
+// rounds to, calling fn on each of them in turn
+// The walk stops at the first error fn returns, which is returned
+func (l *Line) pixels(fn func(x, y int) error) error {
+	// round the end points to the pixels they fall on
+	a, b := l.A.Round(), l.B.Round()
+	x0 := a.X
+	x1 := b.X
+	y0 := a.Y
+	y1 := b.Y
 
 	// compute deltax and figure out the horizontal direction
 	// sx == 1 => "going" right, else left
@@ -63,10 +116,9 @@ func (l *Line) Draw(xpm *xpm.XPM, color string) error {
 	err := dx - dy
 
 	for {
-		// draw the current pixel
-		seterror := xpm.SetPixelCartesian(int(x0), int(y0), color)
-		if seterror != nil {
-			return seterror
+		// visit the current pixel
+		if ferr := fn(x0, y0); ferr != nil {
+			return ferr
 		}
 
 		// break if we're done
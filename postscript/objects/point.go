@@ -1,18 +1,54 @@
 package objects
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+)
 
 // Point is the basic datastructure represing a point by its coordinates
+// The coordinates are real numbers; they are only ever rounded to whole
+// pixels when a shape is drawn
 type Point struct {
-	X, Y int
+	X, Y float64
 }
 
 // String satisfies fmt.Stringer.
 func (p *Point) String() string {
-	return fmt.Sprintf("(%d, %d)", p.X, p.Y)
+	return fmt.Sprintf("(%g, %g)", p.X, p.Y)
 }
 
 // NewPoint returns a newly generated Point structure
-func NewPoint(x, y int) *Point {
+func NewPoint(x, y float64) *Point {
 	return &Point{X: x, Y: y}
 }
+
+// Round returns the pixel the Point falls on, rounding its coordinates to the
+// nearest integers (halves are rounded up)
+func (p *Point) Round() *IntPoint {
+	return NewIntPoint(round(p.X), round(p.Y))
+}
+
+// round rounds a real coordinate to the nearest integer one
+func round(f float64) int {
+	return int(math.Floor(f + 0.5))
+}
+
+// IntPoint is a point with integer coordinates, such as a pixel of an image
+type IntPoint struct {
+	X, Y int
+}
+
+// String satisfies fmt.Stringer.
+func (p *IntPoint) String() string {
+	return fmt.Sprintf("(%d, %d)", p.X, p.Y)
+}
+
+// NewIntPoint returns a newly generated IntPoint structure
+func NewIntPoint(x, y int) *IntPoint {
+	return &IntPoint{X: x, Y: y}
+}
+
+// Point converts the IntPoint to a Point
+func (p *IntPoint) Point() *Point {
+	return NewPoint(float64(p.X), float64(p.Y))
+}
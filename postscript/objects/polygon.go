@@ -0,0 +1,261 @@
+package objects
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// FillRule determines which points are considered to be inside a Polygon
+// whose outline crosses itself or which has several rings
+type FillRule int
+
+const (
+	// NonZero considers a point inside if the rings wind around it a
+	// non-zero number of times, as postscript's fill does
+	NonZero FillRule = iota
+
+	// EvenOdd considers a point inside if a ray from it crosses the rings an
+	// odd number of times, as postscript's eofill does
+	EvenOdd
+)
+
+// String satisfies fmt.Stringer.
+func (r FillRule) String() string {
+	if r == EvenOdd {
+		return "even-odd"
+	}
+	return "nonzero"
+}
+
+// Polygon is a filled area bounded by one or more closed rings of points
+// The last point of each ring is implicitly connected back to the first one
+type Polygon struct {
+	Rings [][]*Point
+	Rule  FillRule
+}
+
+// String satisfies fmt.Stringer.
+func (p *Polygon) String() string {
+	rings := make([]string, len(p.Rings))
+	for i, ring := range p.Rings {
+		points := make([]string, len(ring))
+		for j, pt := range ring {
+			points[j] = pt.String()
+		}
+		rings[i] = "{" + strings.Join(points, " ") + "}"
+	}
+	return fmt.Sprintf("<%s %s>", p.Rule, strings.Join(rings, " "))
+}
+
+// NewPolygon returns a newly generated Polygon structure
+func NewPolygon(rule FillRule, rings ...[]*Point) *Polygon {
+	return &Polygon{Rings: rings, Rule: rule}
+}
+
+// Lines returns the outline of the polygon as a slice of Lines
+func (p *Polygon) Lines() []*Line {
+	lines := []*Line{}
+
+	for _, ring := range p.Rings {
+		for i := range ring {
+			lines = append(lines, NewLine(ring[i], ring[(i+1)%len(ring)]))
+		}
+	}
+
+	return lines
+}
+
+// Bounds returns the smallest rectangle containing the Polygon
+func (p *Polygon) Bounds() *Rect {
+	var r *Rect
+	for _, ring := range p.Rings {
+		r = r.Union(pointsBounds(ring...))
+	}
+	return r
+}
+
+// Transform returns the Polygon whose rings go through the images of the
+// points of this one's
+func (p *Polygon) Transform(t Transformer) Shape {
+	rings := make([][]*Point, len(p.Rings))
+	for i, ring := range p.Rings {
+		rings[i] = make([]*Point, len(ring))
+		for j, pt := range ring {
+			rings[i][j] = t.TransformPoint(pt)
+		}
+	}
+	return NewPolygon(p.Rule, rings...)
+}
+
+// Clip returns the part of the Polygon visible through the given Clipper
+// If the Clipper is a PolygonClipper, the Polygon's area is clipped against it
+// and closed along its edges; otherwise the Polygon is returned whole if any
+// of it may be visible, going by their bounding rectangles
+// NOTE: partially visible Polygons may therefore stick out of Clippers which
+// can only clip Lines
+func (p *Polygon) Clip(c Clipper) ([]Shape, error) {
+	b := p.Bounds()
+	if b == nil || !c.Bounds().Intersects(b) {
+		return nil, nil
+	}
+
+	if pc, ok := c.(PolygonClipper); ok {
+		clipped, err := pc.ClipPolygon(p)
+		if err != nil || clipped == nil {
+			return nil, err
+		}
+		return []Shape{clipped}, nil
+	}
+	return []Shape{p}, nil
+}
+
+// edge is an edge of the polygon as kept in the edge tables of Draw
+type edge struct {
+	// the first and last scanlines crossing the edge
+	first, last int
+
+	// where the edge crosses the current scanline, and how much further
+	// along it crosses the next one
+	x, dxdy float64
+
+	// the winding direction: 1 going up, -1 going down
+	dir int
+}
+
+// edgeTable returns all the edges of the polygon crossing any scanline from
+// miny onward, sorted by the first scanline crossing them
+// The edges include their lower end but not their upper one, so that shared
+// vertices are only ever counted once and horizontal edges not at all
+func (p *Polygon) edgeTable(miny int) []*edge {
+	edges := []*edge{}
+
+	for _, ring := range p.Rings {
+		for i := range ring {
+			a := ring[i]
+			b := ring[(i+1)%len(ring)]
+
+			dir := 1
+			if a.Y > b.Y {
+				a, b = b, a
+				dir = -1
+			}
+
+			first := int(math.Ceil(a.Y))
+			last := int(math.Ceil(b.Y)) - 1
+			if first < miny {
+				first = miny
+			}
+			if first > last {
+				continue
+			}
+
+			dxdy := (b.X - a.X) / (b.Y - a.Y)
+			edges = append(edges, &edge{
+				first: first,
+				last:  last,
+				x:     a.X + (float64(first)-a.Y)*dxdy,
+				dxdy:  dxdy,
+				dir:   dir,
+			})
+		}
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i].first < edges[j].first
+	})
+	return edges
+}
+
+// inside returns whether the given winding number denotes the inside of the
+// polygon under its fill rule
+func (p *Polygon) inside(winding int) bool {
+	if p.Rule == EvenOdd {
+		return winding%2 != 0
+	}
+	return winding != 0
+}
+
+// Contains returns true if the given point lies inside the polygon under its
+// fill rule, going by the number of times its rings wind around the point
+// Points on the outline may be considered on either side of it
+func (p *Polygon) Contains(pt *Point) bool {
+	winding := 0
+	for _, ring := range p.Rings {
+		for i, a := range ring {
+			b := ring[(i+1)%len(ring)]
+			side := (b.X-a.X)*(pt.Y-a.Y) - (pt.X-a.X)*(b.Y-a.Y)
+
+			// upward edges with the point on their left wind around it
+			// counter-clockwise, and downward ones with the point on their
+			// right clockwise
+			switch {
+			case a.Y <= pt.Y && b.Y > pt.Y && side > 0:
+				winding++
+			case a.Y > pt.Y && b.Y <= pt.Y && side < 0:
+				winding--
+			}
+		}
+	}
+	return p.inside(winding)
+}
+
+// Draw takes a Canvas as parameter and proceeds to fill the
+// polygon on it using the color code provided
+// It is the classic active edge table algorithm: going up scanline by
+// scanline, the edges crossing the current one are kept sorted by where they
+// cross it, and the spans between crossings are filled according to the
+// polygon's fill rule
+// Pixels are filled if their centers, at integer coordinates, are inside
+// Unlike Line.Draw, the parts of the polygon outside the image are simply
+// left out
+// NOTE: the given color code has to have been proviously added
+func (p *Polygon) Draw(c Canvas, color string) error {
+	edges := p.edgeTable(0)
+	active := []*edge{}
+
+	for y := 0; y < c.Height() && (len(edges) > 0 || len(active) > 0); y++ {
+		// move the edges starting at this scanline over to the active
+		// ones, and drop the ones which ended before it
+		for len(edges) > 0 && edges[0].first == y {
+			active = append(active, edges[0])
+			edges = edges[1:]
+		}
+		remaining := active[:0]
+		for _, e := range active {
+			if e.last >= y {
+				remaining = append(remaining, e)
+			}
+		}
+		active = remaining
+
+		sort.Slice(active, func(i, j int) bool {
+			return active[i].x < active[j].x
+		})
+
+		// walk the crossings left to right, filling the spans
+		// found to be inside the polygon
+		winding := 0
+		for i := 0; i < len(active)-1; i++ {
+			winding = winding + active[i].dir
+			if !p.inside(winding) {
+				continue
+			}
+
+			// like the scanlines, spans include their left end only
+			x0 := int(math.Ceil(active[i].x))
+			x1 := int(math.Ceil(active[i+1].x)) - 1
+			if err := span(c, x0, x1, y, color); err != nil {
+				return err
+			}
+		}
+
+		// step all the active edges along to the next scanline
+		for _, e := range active {
+			e.x = e.x + e.dxdy
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,320 @@
+package objects
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Polyline is a chain of consecutive Lines, each starting where the previous
+// one ends
+type Polyline struct {
+	Points []*Point
+
+	// Closed connects the last point back to the first one
+	Closed bool
+
+	// Stroke is the way the Polyline is painted; a nil or hairline stroke
+	// has it drawn as plain one-pixel lines
+	Stroke *Stroke
+}
+
+// String satisfies fmt.Stringer.
+func (pl *Polyline) String() string {
+	points := make([]string, len(pl.Points))
+	for i, p := range pl.Points {
+		points[i] = p.String()
+	}
+
+	if pl.Closed {
+		return fmt.Sprintf("{%s}", strings.Join(points, " - "))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(points, " - "))
+}
+
+// NewPolyline returns a newly generated Polyline structure
+func NewPolyline(points ...*Point) *Polyline {
+	return &Polyline{Points: points}
+}
+
+// Lines returns the segments of the Polyline as a slice of Lines
+func (pl *Polyline) Lines() []*Line {
+	lines := []*Line{}
+
+	for i := 1; i < len(pl.Points); i++ {
+		lines = append(lines, NewLine(pl.Points[i-1], pl.Points[i]))
+	}
+	if pl.Closed && len(pl.Points) > 2 {
+		lines = append(lines, NewLine(pl.Points[len(pl.Points)-1], pl.Points[0]))
+	}
+
+	return lines
+}
+
+// Outline returns the area covered by the Polyline's thick stroke as a
+// Polygon, or nil if its stroke is not thick
+func (pl *Polyline) Outline() *Polygon {
+	if !pl.Stroke.IsThick() {
+		return nil
+	}
+	return pl.Stroke.Outline(pl.Points, pl.Closed)
+}
+
+// Bounds returns the smallest rectangle containing the Polyline, including
+// the whole width of its stroke
+func (pl *Polyline) Bounds() *Rect {
+	if outline := pl.Outline(); outline != nil {
+		return outline.Bounds()
+	}
+	return pointsBounds(pl.Points...)
+}
+
+// Transform returns the Polyline going through the images of the points of
+// this one
+// The width of its stroke (and the lengths of its dashes, unless they are
+// measured in pixels) is scaled by the transformation's average scaling
+// factor, as the exact image of a thick stroke under a non-uniform scaling
+// is not a stroke of any single width
+func (pl *Polyline) Transform(t Transformer) Shape {
+	points := make([]*Point, len(pl.Points))
+	for i, p := range pl.Points {
+		points[i] = t.TransformPoint(p)
+	}
+
+	res := &Polyline{Points: points, Closed: pl.Closed}
+	if pl.Stroke != nil {
+		scale := scaleFactor(t)
+		stroke := *pl.Stroke
+		stroke.Width *= scale
+		if stroke.IsDashed() && stroke.DashMeasure == DashByLength {
+			stroke.Dash = make([]float64, len(pl.Stroke.Dash))
+			for i, d := range pl.Stroke.Dash {
+				stroke.Dash[i] = d * scale
+			}
+			stroke.DashOffset *= scale
+		}
+		res.Stroke = &stroke
+	}
+	return res
+}
+
+// Clip returns the parts of the Polyline visible through the given Clipper,
+// as as many open Polylines as there are separate visible stretches of it
+// Each of them keeps the Polyline's stroke
+// Thick Polylines have their outline clipped instead if the Clipper is a
+// PolygonClipper, yielding a Polygon unless the outline is entirely visible;
+// otherwise they are left whole if they are visible at all, so that their
+// caps and joins are not lost
+// Dashed Polylines are split into their dashes first, each of which is
+// clipped in turn, for the pattern not to be shifted by the clipping
+// NOTE: thick strokes may therefore stick out of Clippers which can only clip
+// Lines
+func (pl *Polyline) Clip(c Clipper) ([]Shape, error) {
+	b := pl.Bounds()
+	if b == nil || !c.Bounds().Intersects(b) {
+		return nil, nil
+	}
+
+	if pl.Stroke.IsDashed() {
+		shapes := []Shape{}
+		for _, dash := range pl.dashes() {
+			clipped, err := dash.Clip(c)
+			if err != nil {
+				return nil, err
+			}
+			shapes = append(shapes, clipped...)
+		}
+		return shapes, nil
+	}
+
+	if pc, ok := c.(PolygonClipper); ok {
+		if outline := pl.Outline(); outline != nil {
+			clipped, err := pc.ClipPolygon(outline)
+			switch {
+			case err != nil || clipped == nil:
+				return nil, err
+			case clipped == outline:
+				return []Shape{pl}, nil
+			}
+			return []Shape{clipped}, nil
+		}
+	}
+
+	visible := []*Line{}
+	for _, line := range pl.Lines() {
+		parts, err := ClipSegments(c, line)
+		if err != nil {
+			return nil, err
+		}
+		visible = append(visible, parts...)
+	}
+
+	if pl.Stroke.IsThick() && len(visible) > 0 {
+		return []Shape{pl}, nil
+	}
+
+	shapes := []Shape{}
+	for _, joined := range JoinLines(visible) {
+		joined.Stroke = pl.Stroke
+		shapes = append(shapes, joined)
+	}
+	return shapes, nil
+}
+
+// Draw takes a Canvas as parameter and proceeds to draw all the segments of
+// the Polyline on it using the color code provided, just like Line.Draw
+// All of the segments are drawn even if some of them fail, in which case the
+// first error is returned
+// Thick Polylines are painted by filling their outline instead, and dashed
+// ones have each of their dashes drawn separately
+// NOTE: the given color code has to have been proviously added
+func (pl *Polyline) Draw(c Canvas, color string) error {
+	if pl.Stroke.IsDashed() && !pl.Stroke.IsThick() && pl.Stroke.DashMeasure == DashByPixels {
+		return pl.drawPixelDashes(c, color)
+	}
+
+	var err error
+	for _, dash := range pl.dashes() {
+		if derr := dash.drawSolid(c, color); derr != nil && err == nil {
+			err = derr
+		}
+	}
+	return err
+}
+
+// dashes returns the dashes of the Polyline as solid Polylines with the same
+// stroke, or the Polyline itself if it is not dashed
+func (pl *Polyline) dashes() []*Polyline {
+	if !pl.Stroke.IsDashed() {
+		return []*Polyline{pl}
+	}
+
+	stroke := *pl.Stroke
+	stroke.Dash = nil
+
+	dashes := []*Polyline{}
+	for _, points := range pl.Stroke.Dashes(pl.Points, pl.Closed) {
+		dashes = append(dashes, &Polyline{Points: points, Stroke: &stroke})
+	}
+	return dashes
+}
+
+// drawSolid draws the Polyline ignoring its dash pattern
+func (pl *Polyline) drawSolid(c Canvas, color string) error {
+	if pl.Stroke.IsThick() {
+		if outline := pl.Outline(); outline != nil {
+			return outline.Draw(c, color)
+		}
+		return nil
+	}
+
+	// a dash of no length still shows as a single pixel
+	lines := pl.Lines()
+	if len(pl.Points) == 1 {
+		lines = []*Line{NewLine(pl.Points[0], pl.Points[0])}
+	}
+
+	var err error
+	for _, line := range lines {
+		if lerr := line.Draw(c, color); lerr != nil && err == nil {
+			err = lerr
+		}
+	}
+	return err
+}
+
+// drawPixelDashes draws the thin Polyline pixel by pixel, counting the pixels
+// along it to tell dashes from gaps
+// All of the pixels are drawn even if some of them fail, in which case the
+// first error is returned
+func (pl *Polyline) drawPixelDashes(c Canvas, color string) error {
+	var err error
+
+	n := 0
+	for i, line := range pl.Lines() {
+		// consecutive segments share the pixel of their common point
+		first := i > 0
+		line.pixels(func(x, y int) error {
+			if first {
+				first = false
+				return nil
+			}
+
+			if pl.Stroke.dashOn(n) {
+				if perr := c.SetPixelCartesian(x, y, color); perr != nil && err == nil {
+					err = perr
+				}
+			}
+			n++
+			return nil
+		})
+	}
+	return err
+}
+
+// samePoint returns true if the two points coincide
+func samePoint(a, b *Point) bool {
+	return a.X == b.X && a.Y == b.Y
+}
+
+// JoinLines assembles the given Lines into Polylines, joining every Line
+// which starts where the chain of Lines before it ends onto the same Polyline
+// Lines are never turned around nor moved, so that the Polylines go through
+// them in the same order and direction and are drawn just like them
+// Polylines ending back where they start are closed
+func JoinLines(lines []*Line) []*Polyline {
+	polylines := []*Polyline{}
+
+	var cur *Polyline
+	for _, line := range lines {
+		if cur != nil && samePoint(line.A, cur.Points[len(cur.Points)-1]) {
+			cur.Points = append(cur.Points, line.B)
+			continue
+		}
+
+		cur = NewPolyline(line.A, line.B)
+		polylines = append(polylines, cur)
+	}
+
+	// close the polylines which loop back onto themselves
+	for _, pl := range polylines {
+		n := len(pl.Points)
+		if n > 3 && samePoint(pl.Points[0], pl.Points[n-1]) {
+			pl.Points = pl.Points[:n-1]
+			pl.Closed = true
+		}
+	}
+
+	return polylines
+}
+
+// JoinShapes joins all the runs of consecutive Lines amongst the given shapes
+// into Polylines, as JoinLines does, leaving all other shapes as they are
+// Lines which do not join up with any other are left as they are too
+func JoinShapes(shapes []Shape) []Shape {
+	res := []Shape{}
+	run := []*Line{}
+
+	flush := func() {
+		for _, pl := range JoinLines(run) {
+			if len(pl.Points) == 2 && !pl.Closed {
+				res = append(res, NewLine(pl.Points[0], pl.Points[1]))
+			} else {
+				res = append(res, pl)
+			}
+		}
+		run = []*Line{}
+	}
+
+	for _, shape := range shapes {
+		if line, ok := shape.(*Line); ok {
+			run = append(run, line)
+			continue
+		}
+
+		flush()
+		res = append(res, shape)
+	}
+	flush()
+
+	return res
+}
@@ -0,0 +1,167 @@
+package objects
+
+import (
+	"fmt"
+	"math"
+)
+
+// Shape is implemented by every postscript primitive, so that the whole of a
+// drawing can be drawn, measured, transformed and clipped alike
+type Shape interface {
+	Drawable
+
+	// Bounds returns the smallest rectangle containing the shape
+	Bounds() *Rect
+
+	// Transform returns a new shape with all of its points transformed
+	Transform(t Transformer) Shape
+
+	// Clip returns the parts of the shape visible through the given
+	// Clipper; there are none if the shape lies entirely outside of it
+	Clip(c Clipper) ([]Shape, error)
+}
+
+// Transformer is implemented by all geometric transformations of the plane,
+// such as the ones of the twod package
+type Transformer interface {
+	// TransformPoint returns the image of the given Point
+	TransformPoint(p *Point) *Point
+}
+
+// Clipper is implemented by all clip regions, such as the windows of the
+// clipping package
+// It lives here rather than there so that shapes can clip themselves without
+// the objects package depending on the clipping one
+type Clipper interface {
+	// ClipLine returns the part of the given Line within the region, or
+	// nil if there is none
+	ClipLine(l *Line) (*Line, error)
+
+	// Bounds returns the smallest rectangle containing the region
+	Bounds() *Rect
+}
+
+// SegmentClipper is implemented by the clip regions a Line may go in and out
+// of several times, such as concave polygons
+// Shapes clip their Lines with ClipSegments rather than ClipLine whenever
+// their Clipper is a SegmentClipper
+type SegmentClipper interface {
+	Clipper
+
+	// ClipSegments returns all the parts of the given Line within the
+	// region, in order along the Line
+	ClipSegments(l *Line) ([]*Line, error)
+}
+
+// ClipSegments returns all the parts of the given Line visible through the
+// given Clipper, in order along the Line
+func ClipSegments(c Clipper, l *Line) ([]*Line, error) {
+	if sc, ok := c.(SegmentClipper); ok {
+		return sc.ClipSegments(l)
+	}
+
+	cl, err := c.ClipLine(l)
+	if err != nil || cl == nil {
+		return nil, err
+	}
+	return []*Line{cl}, nil
+}
+
+// PolygonClipper is implemented by the clip regions which can clip areas
+// rather than just outlines, such as the windows of the clipping package
+// Filled shapes are clipped with ClipPolygon whenever their Clipper is a
+// PolygonClipper, so that they stay closed along the edges of the region
+type PolygonClipper interface {
+	Clipper
+
+	// ClipPolygon returns the part of the given Polygon's area within the
+	// region, or nil if there is none
+	ClipPolygon(p *Polygon) (*Polygon, error)
+}
+
+// CurveClipper is implemented by the clip regions which can clip curves
+// exactly, such as the rectangular windows of the clipping package
+// Curves are clipped with it whenever their Clipper is a CurveClipper, rather
+// than being approximated by Lines first
+type CurveClipper interface {
+	Clipper
+
+	// ClipArc returns the parts of the given Arc within the region, in
+	// order along it
+	ClipArc(a *Arc) ([]*Arc, error)
+
+	// ClipBezier returns the parts of the given Bézier curve within the
+	// region, in order along it
+	ClipBezier(b *Bezier) ([]*Bezier, error)
+}
+
+// Rect is an axis-aligned rectangle given by its lower left and upper right
+// corners
+type Rect struct {
+	LLX, LLY, URX, URY float64
+}
+
+// String satisfies fmt.Stringer.
+func (r *Rect) String() string {
+	return fmt.Sprintf("[(%g, %g) - (%g, %g)]", r.LLX, r.LLY, r.URX, r.URY)
+}
+
+// NewRect returns a newly generated Rect structure
+func NewRect(llx, lly, urx, ury float64) *Rect {
+	return &Rect{LLX: llx, LLY: lly, URX: urx, URY: ury}
+}
+
+// pointsBounds returns the smallest Rect containing all the given points
+// It returns nil if there are no points at all
+func pointsBounds(points ...*Point) *Rect {
+	if len(points) == 0 {
+		return nil
+	}
+
+	r := NewRect(math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1))
+	for _, p := range points {
+		r.LLX = math.Min(r.LLX, p.X)
+		r.LLY = math.Min(r.LLY, p.Y)
+		r.URX = math.Max(r.URX, p.X)
+		r.URY = math.Max(r.URY, p.Y)
+	}
+	return r
+}
+
+// Union returns the smallest Rect containing both Rects
+// Either of them may be nil, standing in for an empty rectangle
+func (r *Rect) Union(other *Rect) *Rect {
+	if r == nil {
+		return other
+	}
+	if other == nil {
+		return r
+	}
+
+	return NewRect(
+		math.Min(r.LLX, other.LLX), math.Min(r.LLY, other.LLY),
+		math.Max(r.URX, other.URX), math.Max(r.URY, other.URY),
+	)
+}
+
+// Intersects returns true if the two Rects have any point in common
+func (r *Rect) Intersects(other *Rect) bool {
+	return r.LLX <= other.URX && other.LLX <= r.URX &&
+		r.LLY <= other.URY && other.LLY <= r.URY
+}
+
+// Contains returns true if the other Rect lies entirely within this one
+func (r *Rect) Contains(other *Rect) bool {
+	return r.LLX <= other.LLX && other.URX <= r.URX &&
+		r.LLY <= other.LLY && other.URY <= r.URY
+}
+
+// ShapesBounds returns the smallest Rect containing all the given shapes
+// It returns nil if there are no shapes at all
+func ShapesBounds(shapes []Shape) *Rect {
+	var r *Rect
+	for _, shape := range shapes {
+		r = r.Union(shape.Bounds())
+	}
+	return r
+}
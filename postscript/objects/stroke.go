@@ -0,0 +1,284 @@
+package objects
+
+import (
+	"fmt"
+	"math"
+)
+
+// LineCap is the shape given to the open ends of thick strokes
+// Its values match postscript's setlinecap
+type LineCap int
+
+const (
+	// ButtCap ends the stroke squarely at its end points
+	ButtCap LineCap = iota
+
+	// RoundCap ends the stroke in a half circle around its end points
+	RoundCap
+
+	// SquareCap ends the stroke squarely, half the line width beyond its
+	// end points
+	SquareCap
+)
+
+// String satisfies fmt.Stringer.
+func (c LineCap) String() string {
+	switch c {
+	case RoundCap:
+		return "round"
+	case SquareCap:
+		return "square"
+	}
+	return "butt"
+}
+
+// LineJoin is the shape given to the corners between consecutive segments
+// of thick strokes
+// Its values match postscript's setlinejoin
+type LineJoin int
+
+const (
+	// MiterJoin extends the outer edges of both segments until they meet,
+	// unless that goes beyond the miter limit
+	MiterJoin LineJoin = iota
+
+	// RoundJoin rounds the corner off with a circle around the vertex
+	RoundJoin
+
+	// BevelJoin cuts the corner off with a straight edge
+	BevelJoin
+)
+
+// String satisfies fmt.Stringer.
+func (j LineJoin) String() string {
+	switch j {
+	case RoundJoin:
+		return "round"
+	case BevelJoin:
+		return "bevel"
+	}
+	return "miter"
+}
+
+// Stroke describes how the outline of a path is painted, as postscript's
+// setlinewidth, setlinecap, setlinejoin, setmiterlimit and setdash do
+type Stroke struct {
+	Width float64
+	Cap   LineCap
+	Join  LineJoin
+
+	// MiterLimit is the largest ratio between the length of a miter and the
+	// line width; sharper corners are beveled instead
+	MiterLimit float64
+
+	// Dash is the dash pattern, DashOffset the distance into it the path
+	// starts at and DashMeasure how the pattern's lengths are measured
+	// An empty pattern strokes solid lines
+	Dash        []float64
+	DashOffset  float64
+	DashMeasure DashMeasure
+}
+
+// String satisfies fmt.Stringer.
+func (s *Stroke) String() string {
+	if s.IsDashed() {
+		return fmt.Sprintf("<stroke %g %s %s %g %v %g %s>", s.Width, s.Cap, s.Join,
+			s.MiterLimit, s.Dash, s.DashOffset, s.DashMeasure)
+	}
+	return fmt.Sprintf("<stroke %g %s %s %g>", s.Width, s.Cap, s.Join, s.MiterLimit)
+}
+
+// NewStroke returns a newly generated Stroke structure of the given width,
+// with postscript's default butt caps, miter joins and miter limit of 10
+func NewStroke(width float64) *Stroke {
+	return &Stroke{Width: width, Cap: ButtCap, Join: MiterJoin, MiterLimit: 10}
+}
+
+// IsThick returns true if strokes of this kind are wider than a single pixel,
+// and have to be painted as outlines rather than drawn as plain lines
+func (s *Stroke) IsThick() bool {
+	return s != nil && s.Width > 1
+}
+
+// ringArea returns twice the signed area of the given ring, positive if it
+// goes counter-clockwise
+func ringArea(ring []*Point) float64 {
+	area := 0.0
+	for i, p := range ring {
+		q := ring[(i+1)%len(ring)]
+		area += p.X*q.Y - q.X*p.Y
+	}
+	return area
+}
+
+// counterClockwise returns the given ring, reversed if it goes clockwise
+func counterClockwise(ring []*Point) []*Point {
+	if ringArea(ring) >= 0 {
+		return ring
+	}
+
+	res := make([]*Point, len(ring))
+	for i, p := range ring {
+		res[len(ring)-1-i] = p
+	}
+	return res
+}
+
+// offset returns the point at the given multiples of the two vectors from p
+func offset(p *Point, a float64, u *Point, b float64, v *Point) *Point {
+	return NewPoint(p.X+a*u.X+b*v.X, p.Y+a*u.Y+b*v.Y)
+}
+
+// Outline returns the area covered by stroking the polyline going through
+// the given points (and back to the first one if closed) as a Polygon
+// The outline is made up of a rectangle around every segment, the caps at
+// the ends of an open polyline and the joins at its corners, all of them
+// going counter-clockwise so that the nonzero fill rule paints their union
+// Nil is returned if the stroke covers no area at all
+func (s *Stroke) Outline(points []*Point, closed bool) *Polygon {
+	h := s.Width / 2
+	if h <= 0 {
+		return nil
+	}
+
+	// consecutive duplicate points have no direction
+	pts := []*Point{}
+	for _, p := range points {
+		if len(pts) == 0 || !samePoint(p, pts[len(pts)-1]) {
+			pts = append(pts, p)
+		}
+	}
+	if closed && len(pts) > 1 && samePoint(pts[0], pts[len(pts)-1]) {
+		pts = pts[:len(pts)-1]
+	}
+
+	if len(pts) == 0 {
+		return nil
+	}
+
+	// a lone point only shows if it has round or square caps
+	if len(pts) == 1 {
+		p := pts[0]
+		switch s.Cap {
+		case RoundCap:
+			return NewPolygon(NonZero, discRing(p, h))
+		case SquareCap:
+			return NewPolygon(NonZero, []*Point{
+				NewPoint(p.X-h, p.Y-h), NewPoint(p.X+h, p.Y-h),
+				NewPoint(p.X+h, p.Y+h), NewPoint(p.X-h, p.Y+h),
+			})
+		}
+		return nil
+	}
+
+	// the unit directions and left-hand normals of all n segments
+	n := len(pts) - 1
+	if closed && len(pts) > 2 {
+		n = len(pts)
+	} else {
+		closed = false
+	}
+	dirs := make([]*Point, n)
+	normals := make([]*Point, n)
+	for i := 0; i < n; i++ {
+		a, b := pts[i], pts[(i+1)%len(pts)]
+		l := math.Hypot(b.X-a.X, b.Y-a.Y)
+		dirs[i] = NewPoint((b.X-a.X)/l, (b.Y-a.Y)/l)
+		normals[i] = NewPoint(-dirs[i].Y, dirs[i].X)
+	}
+
+	// the segments, extended by the square caps at the ends
+	rings := [][]*Point{}
+	for i := 0; i < n; i++ {
+		a, b := pts[i], pts[(i+1)%len(pts)]
+		ea, eb := 0.0, 0.0
+		if !closed && s.Cap == SquareCap {
+			if i == 0 {
+				ea = h
+			}
+			if i == n-1 {
+				eb = h
+			}
+		}
+
+		d, nl := dirs[i], normals[i]
+		rings = append(rings, []*Point{
+			offset(a, -ea, d, -h, nl), offset(b, eb, d, -h, nl),
+			offset(b, eb, d, h, nl), offset(a, -ea, d, h, nl),
+		})
+	}
+
+	// the round caps
+	if !closed && s.Cap == RoundCap {
+		rings = append(rings, discRing(pts[0], h), discRing(pts[len(pts)-1], h))
+	}
+
+	// the joins, at every vertex between two segments; all of them if the
+	// polyline is closed, or all but its two ends if it is not
+	first := 1
+	if closed {
+		first = 0
+	}
+	for i := first; i < n; i++ {
+		prev := (i - 1 + n) % n
+		if ring := s.join(pts[i], dirs[prev], normals[prev], dirs[i], normals[i]); ring != nil {
+			rings = append(rings, ring)
+		}
+	}
+
+	return NewPolygon(NonZero, rings...)
+}
+
+// discRing returns a counter-clockwise ring around the disc of radius r
+// centered in c
+func discRing(c *Point, r float64) []*Point {
+	return counterClockwise(NewEllipse(c, r, r, 0).Outline(clipFlatness))
+}
+
+// join returns the ring filling the corner at v between a segment going in
+// the unit direction d1 (with left-hand normal n1) and the next one going in
+// the direction d2 (with normal n2), or nil if there is no corner to fill
+func (s *Stroke) join(v, d1, n1, d2, n2 *Point) []*Point {
+	h := s.Width / 2
+	cross := d1.X*d2.Y - d1.Y*d2.X
+
+	if s.Join == RoundJoin {
+		return discRing(v, h)
+	}
+	if math.Abs(cross) < 1e-12 {
+		return nil
+	}
+
+	// the corner sticks out to the right of left turns and vice versa
+	side := h
+	if cross > 0 {
+		side = -h
+	}
+	o1 := offset(v, side, n1, 0, n2)
+	o2 := offset(v, 0, n1, side, n2)
+
+	if s.Join == MiterJoin {
+		// the miter's tip lies where the outer edges meet, along the
+		// bisector of the two normals, its length relative to the width
+		// being 1/sin of half the angle between the segments
+		ratio := 1 / math.Sqrt((1+n1.X*n2.X+n1.Y*n2.Y)/2)
+		if ratio <= s.MiterLimit {
+			bx, by := n1.X+n2.X, n1.Y+n2.Y
+			l := math.Hypot(bx, by)
+			tip := NewPoint(v.X+bx/l*side*ratio, v.Y+by/l*side*ratio)
+			return counterClockwise([]*Point{v, o1, tip, o2})
+		}
+	}
+
+	return counterClockwise([]*Point{v, o1, o2})
+}
+
+// scaleFactor returns the factor by which the given (affine) transformation
+// scales lengths on average, i.e. the square root of the factor by which it
+// scales areas
+func scaleFactor(t Transformer) float64 {
+	o := t.TransformPoint(NewPoint(0, 0))
+	u := t.TransformPoint(NewPoint(1, 0))
+	v := t.TransformPoint(NewPoint(0, 1))
+	return math.Sqrt(math.Abs((u.X-o.X)*(v.Y-o.Y) - (u.Y-o.Y)*(v.X-o.X)))
+}
@@ -0,0 +1,665 @@
+package postscript
+
+import (
+	"fmt"  // for fmt.Errorf
+	"math" // for math.Abs
+
+	"../clipping"
+	"./objects"
+)
+
+// systemdict maps the names of all builtin operators to their implementation
+// It is populated in init to avoid an initialization cycle, as some of the
+// operators themselves need to look names up
+var systemdict map[string]operator
+
+func init() {
+	systemdict = map[string]operator{
+		// stack manipulation
+		"pop":  opPop,
+		"dup":  opDup,
+		"exch": opExch,
+
+		// arithmetic
+		"add": arithmetic(func(a, b float64) float64 { return a + b }),
+		"sub": arithmetic(func(a, b float64) float64 { return a - b }),
+		"mul": arithmetic(func(a, b float64) float64 { return a * b }),
+		"div": opDiv,
+		"neg": opNeg,
+
+		// arrays
+		"[": opMark,
+		"]": opArray,
+
+		// definitions
+		"def":  opDef,
+		"bind": opBind,
+
+		// graphics state
+		"gsave":       opGsave,
+		"grestore":    opGrestore,
+		"setflat":     opSetflat,
+		"currentflat": opCurrentflat,
+
+		"setlinewidth":      opSetlinewidth,
+		"currentlinewidth":  opCurrentlinewidth,
+		"setlinecap":        opSetlinecap,
+		"currentlinecap":    opCurrentlinecap,
+		"setlinejoin":       opSetlinejoin,
+		"currentlinejoin":   opCurrentlinejoin,
+		"setmiterlimit":     opSetmiterlimit,
+		"currentmiterlimit": opCurrentmiterlimit,
+		"setdash":           opSetdash,
+		"currentdash":       opCurrentdash,
+
+		// path construction
+		"newpath":      opNewpath,
+		"currentpoint": opCurrentpoint,
+		"moveto":       opMoveto,
+		"rmoveto":      opRmoveto,
+		"lineto":       opLineto,
+		"rlineto":      opRlineto,
+		"curveto":      opCurveto,
+		"rcurveto":     opRcurveto,
+		"arc":          opArc,
+		"arcn":         opArcn,
+		"arct":         opArct,
+		"closepath":    opClosepath,
+
+		// painting
+		"stroke":     opStroke,
+		"fill":       opFill,
+		"eofill":     opEofill,
+		"rectstroke": opRectstroke,
+		"rectfill":   opRectfill,
+		"showpage":   opShowpage,
+
+		// clipping
+		"clip":     opClip,
+		"eoclip":   opEoclip,
+		"rectclip": opRectclip,
+		"initclip": opInitclip,
+
+		// text
+		"findfont":    opFindfont,
+		"scalefont":   opScalefont,
+		"setfont":     opSetfont,
+		"currentfont": opCurrentfont,
+		"selectfont":  opSelectfont,
+		"show":        opShow,
+		"stringwidth": opStringwidth,
+
+		// x1 y1 x2 y2 Line is the line definition convention of the
+		// input files, which is honoured even if they do not define it
+		"Line": opLine,
+	}
+}
+
+// opPop implements: any pop -
+func opPop(in *Interpreter) error {
+	_, err := in.pop()
+	return err
+}
+
+// opDup implements: any dup any any
+func opDup(in *Interpreter) error {
+	obj, err := in.pop()
+	if err != nil {
+		return err
+	}
+
+	in.push(obj)
+	in.push(obj)
+	return nil
+}
+
+// opExch implements: any1 any2 exch any2 any1
+func opExch(in *Interpreter) error {
+	b, err := in.pop()
+	if err != nil {
+		return err
+	}
+	a, err := in.pop()
+	if err != nil {
+		return err
+	}
+
+	in.push(b)
+	in.push(a)
+	return nil
+}
+
+// arithmetic returns an operator applying f to the two topmost numbers
+func arithmetic(f func(a, b float64) float64) operator {
+	return func(in *Interpreter) error {
+		nums, err := in.popNumbers(2)
+		if err != nil {
+			return err
+		}
+
+		in.push(f(nums[0], nums[1]))
+		return nil
+	}
+}
+
+// opDiv implements: num1 num2 div quotient
+func opDiv(in *Interpreter) error {
+	nums, err := in.popNumbers(2)
+	if err != nil {
+		return err
+	}
+	if nums[1] == 0 {
+		return fmt.Errorf("Undefined result: division by zero")
+	}
+
+	in.push(nums[0] / nums[1])
+	return nil
+}
+
+// opNeg implements: num neg -num
+func opNeg(in *Interpreter) error {
+	nums, err := in.popNumbers(1)
+	if err != nil {
+		return err
+	}
+
+	in.push(-nums[0])
+	return nil
+}
+
+// opMark implements: - [ mark
+func opMark(in *Interpreter) error {
+	in.push(mark{})
+	return nil
+}
+
+// opArray implements: mark obj0 ... objn-1 ] array
+// It collects everything pushed since the topmost mark into an array
+func opArray(in *Interpreter) error {
+	for i := len(in.stack) - 1; i >= 0; i-- {
+		if _, ok := in.stack[i].(mark); ok {
+			arr := append(array{}, in.stack[i+1:]...)
+			in.stack = in.stack[:i]
+			in.push(arr)
+			return nil
+		}
+	}
+	return fmt.Errorf("Unmatched ]")
+}
+
+// opDef implements: /key value def -
+func opDef(in *Interpreter) error {
+	val, err := in.pop()
+	if err != nil {
+		return err
+	}
+	key, err := in.pop()
+	if err != nil {
+		return err
+	}
+
+	lit, ok := key.(literal)
+	if !ok {
+		return fmt.Errorf("Type check: expected literal name, got %v", key)
+	}
+
+	in.userdict[string(lit)] = val
+	return nil
+}
+
+// opBind implements: proc bind proc
+// Operator lookups are cheap enough here for bind to do nothing at all
+func opBind(in *Interpreter) error {
+	obj, err := in.pop()
+	if err != nil {
+		return err
+	}
+	if _, ok := obj.(procedure); !ok {
+		return fmt.Errorf("Type check: expected procedure, got %v", obj)
+	}
+
+	in.push(obj)
+	return nil
+}
+
+// opGsave implements: - gsave -
+func opGsave(in *Interpreter) error {
+	in.gsaves = append(in.gsaves, in.gs.copy())
+	return nil
+}
+
+// opGrestore implements: - grestore -
+// With no matching gsave, it leaves the graphics state untouched
+func opGrestore(in *Interpreter) error {
+	if n := len(in.gsaves); n > 0 {
+		in.gs = in.gsaves[n-1]
+		in.gsaves = in.gsaves[:n-1]
+	}
+	return nil
+}
+
+// opSetflat implements: num setflat -
+func opSetflat(in *Interpreter) error {
+	nums, err := in.popNumbers(1)
+	if err != nil {
+		return err
+	}
+
+	in.SetFlatness(nums[0])
+	return nil
+}
+
+// opCurrentflat implements: - currentflat num
+func opCurrentflat(in *Interpreter) error {
+	in.push(in.gs.flat)
+	return nil
+}
+
+// opSetlinewidth implements: num setlinewidth -
+// As in most postscript implementations, negative widths count as positive
+func opSetlinewidth(in *Interpreter) error {
+	nums, err := in.popNumbers(1)
+	if err != nil {
+		return err
+	}
+
+	in.gs.stroke.Width = math.Abs(nums[0])
+	return nil
+}
+
+// opCurrentlinewidth implements: - currentlinewidth num
+func opCurrentlinewidth(in *Interpreter) error {
+	in.push(in.gs.stroke.Width)
+	return nil
+}
+
+// popStyle pops the number of a line cap or join style off the stack,
+// which has to be one of 0, 1 and 2
+func (in *Interpreter) popStyle() (int, error) {
+	nums, err := in.popNumbers(1)
+	if err != nil {
+		return 0, err
+	}
+
+	style := int(nums[0])
+	if float64(style) != nums[0] || style < 0 || style > 2 {
+		return 0, fmt.Errorf("Invalid line style %g", nums[0])
+	}
+	return style, nil
+}
+
+// opSetlinecap implements: int setlinecap -
+func opSetlinecap(in *Interpreter) error {
+	style, err := in.popStyle()
+	if err != nil {
+		return err
+	}
+
+	in.gs.stroke.Cap = objects.LineCap(style)
+	return nil
+}
+
+// opCurrentlinecap implements: - currentlinecap int
+func opCurrentlinecap(in *Interpreter) error {
+	in.push(float64(in.gs.stroke.Cap))
+	return nil
+}
+
+// opSetlinejoin implements: int setlinejoin -
+func opSetlinejoin(in *Interpreter) error {
+	style, err := in.popStyle()
+	if err != nil {
+		return err
+	}
+
+	in.gs.stroke.Join = objects.LineJoin(style)
+	return nil
+}
+
+// opCurrentlinejoin implements: - currentlinejoin int
+func opCurrentlinejoin(in *Interpreter) error {
+	in.push(float64(in.gs.stroke.Join))
+	return nil
+}
+
+// opSetmiterlimit implements: num setmiterlimit -
+func opSetmiterlimit(in *Interpreter) error {
+	nums, err := in.popNumbers(1)
+	if err != nil {
+		return err
+	}
+
+	if nums[0] < 1 {
+		return fmt.Errorf("Invalid miter limit %g", nums[0])
+	}
+	in.gs.stroke.MiterLimit = nums[0]
+	return nil
+}
+
+// opCurrentmiterlimit implements: - currentmiterlimit num
+func opCurrentmiterlimit(in *Interpreter) error {
+	in.push(in.gs.stroke.MiterLimit)
+	return nil
+}
+
+// opSetdash implements: array offset setdash -
+func opSetdash(in *Interpreter) error {
+	nums, err := in.popNumbers(1)
+	if err != nil {
+		return err
+	}
+
+	obj, err := in.pop()
+	if err != nil {
+		return err
+	}
+	arr, ok := obj.(array)
+	if !ok {
+		return fmt.Errorf("Type check: expected array, got %v", obj)
+	}
+
+	dash := make([]float64, len(arr))
+	for i, d := range arr {
+		f, ok := d.(float64)
+		if !ok {
+			return fmt.Errorf("Type check: expected number, got %v", d)
+		}
+		dash[i] = f
+	}
+
+	return in.gs.stroke.SetDash(dash, nums[0])
+}
+
+// opCurrentdash implements: - currentdash array offset
+func opCurrentdash(in *Interpreter) error {
+	arr := make(array, len(in.gs.stroke.Dash))
+	for i, d := range in.gs.stroke.Dash {
+		arr[i] = d
+	}
+
+	in.push(arr)
+	in.push(in.gs.stroke.DashOffset)
+	return nil
+}
+
+// opNewpath implements: - newpath -
+func opNewpath(in *Interpreter) error {
+	in.newPath()
+	return nil
+}
+
+// opCurrentpoint implements: - currentpoint x y
+func opCurrentpoint(in *Interpreter) error {
+	if !in.gs.hasCur {
+		return fmt.Errorf("No current point")
+	}
+
+	in.push(in.gs.current.x)
+	in.push(in.gs.current.y)
+	return nil
+}
+
+// opMoveto implements: x y moveto -
+func opMoveto(in *Interpreter) error {
+	nums, err := in.popNumbers(2)
+	if err != nil {
+		return err
+	}
+
+	in.moveTo(point{nums[0], nums[1]})
+	return nil
+}
+
+// opRmoveto implements: dx dy rmoveto -
+func opRmoveto(in *Interpreter) error {
+	nums, err := in.popNumbers(2)
+	if err != nil {
+		return err
+	}
+	if !in.gs.hasCur {
+		return fmt.Errorf("No current point")
+	}
+
+	cur := in.gs.current
+	in.moveTo(point{cur.x + nums[0], cur.y + nums[1]})
+	return nil
+}
+
+// opLineto implements: x y lineto -
+func opLineto(in *Interpreter) error {
+	nums, err := in.popNumbers(2)
+	if err != nil {
+		return err
+	}
+
+	return in.lineTo(point{nums[0], nums[1]})
+}
+
+// opRlineto implements: dx dy rlineto -
+func opRlineto(in *Interpreter) error {
+	nums, err := in.popNumbers(2)
+	if err != nil {
+		return err
+	}
+	if !in.gs.hasCur {
+		return fmt.Errorf("No current point")
+	}
+
+	cur := in.gs.current
+	return in.lineTo(point{cur.x + nums[0], cur.y + nums[1]})
+}
+
+// opCurveto implements: x1 y1 x2 y2 x3 y3 curveto -
+func opCurveto(in *Interpreter) error {
+	nums, err := in.popNumbers(6)
+	if err != nil {
+		return err
+	}
+	if !in.gs.hasCur {
+		return fmt.Errorf("No current point")
+	}
+
+	return in.curveTo(
+		point{nums[0], nums[1]},
+		point{nums[2], nums[3]},
+		point{nums[4], nums[5]},
+	)
+}
+
+// opRcurveto implements: dx1 dy1 dx2 dy2 dx3 dy3 rcurveto -
+// All three points are relative to the current point
+func opRcurveto(in *Interpreter) error {
+	nums, err := in.popNumbers(6)
+	if err != nil {
+		return err
+	}
+	if !in.gs.hasCur {
+		return fmt.Errorf("No current point")
+	}
+
+	cur := in.gs.current
+	return in.curveTo(
+		point{cur.x + nums[0], cur.y + nums[1]},
+		point{cur.x + nums[2], cur.y + nums[3]},
+		point{cur.x + nums[4], cur.y + nums[5]},
+	)
+}
+
+// opArc implements: x y r angle1 angle2 arc -
+// The arc is drawn counter-clockwise
+func opArc(in *Interpreter) error {
+	nums, err := in.popNumbers(5)
+	if err != nil {
+		return err
+	}
+
+	a1, a2, err := arcAngles(nums[3], nums[4], false)
+	if err != nil {
+		return err
+	}
+
+	return in.arc(point{nums[0], nums[1]}, nums[2], a1, a2)
+}
+
+// opArcn implements: x y r angle1 angle2 arcn -
+// The arc is drawn clockwise
+func opArcn(in *Interpreter) error {
+	nums, err := in.popNumbers(5)
+	if err != nil {
+		return err
+	}
+
+	a1, a2, err := arcAngles(nums[3], nums[4], true)
+	if err != nil {
+		return err
+	}
+
+	return in.arc(point{nums[0], nums[1]}, nums[2], a1, a2)
+}
+
+// opArct implements: x1 y1 x2 y2 r arct -
+func opArct(in *Interpreter) error {
+	nums, err := in.popNumbers(5)
+	if err != nil {
+		return err
+	}
+
+	return in.arcTo(point{nums[0], nums[1]}, point{nums[2], nums[3]}, nums[4])
+}
+
+// opClosepath implements: - closepath -
+func opClosepath(in *Interpreter) error {
+	in.closePath()
+	return nil
+}
+
+// opStroke implements: - stroke -
+func opStroke(in *Interpreter) error {
+	if err := in.strokePath(); err != nil {
+		return err
+	}
+
+	in.newPath()
+	return nil
+}
+
+// opFill implements: - fill -
+func opFill(in *Interpreter) error {
+	if err := in.fillPath(objects.NonZero); err != nil {
+		return err
+	}
+
+	in.newPath()
+	return nil
+}
+
+// opEofill implements: - eofill -
+func opEofill(in *Interpreter) error {
+	if err := in.fillPath(objects.EvenOdd); err != nil {
+		return err
+	}
+
+	in.newPath()
+	return nil
+}
+
+// rectPath replaces the current path with the rectangle described by the
+// four topmost numbers on the stack (x y width height)
+// It returns the path it replaced so that it can be put back after painting,
+// as rectfill and rectstroke leave the current path untouched
+func (in *Interpreter) rectPath() ([]*subpath, error) {
+	nums, err := in.popNumbers(4)
+	if err != nil {
+		return nil, err
+	}
+
+	x, y, w, h := nums[0], nums[1], nums[2], nums[3]
+	saved := in.gs.path
+
+	in.gs.path = []*subpath{
+		{
+			points: []point{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}},
+			closed: true,
+		},
+	}
+
+	return saved, nil
+}
+
+// opRectfill implements: x y width height rectfill -
+func opRectfill(in *Interpreter) error {
+	saved, err := in.rectPath()
+	if err != nil {
+		return err
+	}
+
+	err = in.fillPath(objects.NonZero)
+	in.gs.path = saved
+	return err
+}
+
+// opRectstroke implements: x y width height rectstroke -
+func opRectstroke(in *Interpreter) error {
+	saved, err := in.rectPath()
+	if err != nil {
+		return err
+	}
+
+	err = in.strokePath()
+	in.gs.path = saved
+	return err
+}
+
+// opClip implements: - clip -
+// Like postscript's, it leaves the current path untouched, for it to be
+// painted as well
+func opClip(in *Interpreter) error {
+	in.clipPath(objects.NonZero)
+	return nil
+}
+
+// opEoclip implements: - eoclip -
+func opEoclip(in *Interpreter) error {
+	in.clipPath(objects.EvenOdd)
+	return nil
+}
+
+// opRectclip implements: x y width height rectclip -
+// Unlike clip, it clears the current path
+func opRectclip(in *Interpreter) error {
+	if _, err := in.rectPath(); err != nil {
+		return err
+	}
+
+	in.clipPath(objects.NonZero)
+	in.newPath()
+	return nil
+}
+
+// opInitclip implements: - initclip -
+// It resets the clipping path to the whole page, which is unbounded here
+func opInitclip(in *Interpreter) error {
+	in.gs.clip = clipping.NewClipStack()
+	return nil
+}
+
+// opShowpage implements: - showpage -
+// Everything painted from here onward goes on the next page
+func opShowpage(in *Interpreter) error {
+	in.showPage()
+	return nil
+}
+
+// opLine implements: x1 y1 x2 y2 Line -
+// It paints the Line from (x1, y1) to (x2, y2) and leaves the path untouched
+func opLine(in *Interpreter) error {
+	nums, err := in.popNumbers(4)
+	if err != nil {
+		return err
+	}
+
+	return in.paint(objects.NewLine(
+		toPoint(point{nums[0], nums[1]}),
+		toPoint(point{nums[2], nums[3]}),
+	))
+}
@@ -1,40 +1,22 @@
 package postscript
 
 import (
-	"io/ioutil" // for ioutil.ReadFile
-	"strconv"   // for strconv.Atoi
-	"strings"   // for strings.Fields
+	"fmt"           // for fmt.Sprintf
+	"os"            // for os.Open
+	"path/filepath" // for filepath.Ext
 
 	// where all out postscript objects are defined:
 	"./objects"
 )
 
-// atois take a slice of string representations of numbers and applies atoi on
-// each, returning the resulting list
-// if any error occurs for a conversion; the function will promptly return it
-func atois(strings []string) ([]int, error) {
-	ints := []int{}
-
-	for _, str := range strings {
-		// attempt conversion to int
-		n, err := strconv.Atoi(str)
-		if err != nil {
-			return nil, err
-		}
-
-		// cast to int and append to result
-		ints = append(ints, int(n))
-	}
-
-	return ints, nil
-}
-
-// ParseFile parses a postscript file and returns a slice of all Line objects
-// defined in the file
-// NOTE: only accepts files of this form:
+// ParseFile interprets a postscript file and returns a slice of all the Line
+// objects it strokes, as ShapesToLines breaks them up; anything it fills is
+// left out
+// Besides the usual line definitions of the form:
 //
 // example.ps
 //
+// /Line {moveto lineto stroke} bind def
 // %%%BEGIN
 // x11 y11 x12 y12 Line
 // x21 y21 x22 y22 Line
@@ -42,39 +24,135 @@ func atois(strings []string) ([]int, error) {
 // xn1 yn1 xn2 yn2 Line
 // %%%END
 //
-// Newlines and anything above and below the BEGIN and END tags is ignored
+// paths built with moveto, lineto, curveto, arc and the like are accepted too,
+// with any curves flattened into Lines within DefaultFlatness (or whatever
+// flatness the file sets through setflat)
+// Comments (and so the BEGIN and END tags) are ignored
+// The Lines of all of a multi-page file's pages are returned together; use
+// ParsePages to tell them apart
 func ParseFile(filename string) ([]*objects.Line, error) {
-	// read out the file's contents
-	contents, err := ioutil.ReadFile(filename)
+	// a slice in which to store our parsed lines
+	lines := []*objects.Line{}
+
+	err := WalkFile(filename, func(shape objects.Shape) error {
+		lines = append(lines, ShapesToLines([]objects.Shape{shape})...)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// a slice in which to store our parsed lines
-	lines := []*objects.Line{}
+	return lines, nil
+}
 
-	// convert raw bytes to string and split into fields
-	items := strings.Fields(string(contents))
+// ParsePages interprets a postscript file just like ParseShapes does, but
+// splits up what it paints into the pages ended by its showpage calls
+// A file which never calls showpage yields a single page
+func ParsePages(filename string) ([][]objects.Shape, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
 
-	// pass through all items and interpret all fields preceding "Line"
-	for i, item := range items {
-		if item == "Line" {
-			// fetch ints preceding "Line"
-			ints, err := atois(items[i-4 : i])
-			if err != nil {
-				return nil, err
-			}
+	d := NewDecoder(f)
+	pages := [][]objects.Shape{}
 
-			// create new line from fetched values
-			line := objects.NewLine(
-				objects.NewPoint(ints[0], ints[1]),
-				objects.NewPoint(ints[2], ints[3]),
-			)
+	err = d.WalkPages(func(page int, shape objects.Shape) error {
+		for len(pages) < page {
+			pages = append(pages, []objects.Shape{})
+		}
+		pages[page-1] = append(pages[page-1], shape)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// account for any blank pages at the end
+	for len(pages) < d.PageCount() {
+		pages = append(pages, []objects.Shape{})
+	}
 
-			// add the newly created line to the list of returned lines
-			lines = append(lines, line)
+	return pages, nil
+}
+
+// ShapesToLines returns all the Lines amongst the given shapes, breaking up
+// any Polylines into their segments (or those of their dashes) and
+// flattening the outlines of Circles, Ellipses, Arcs and Bézier curves
+// within DefaultFlatness; filled shapes are left out
+func ShapesToLines(shapes []objects.Shape) []*objects.Line {
+	lines := []*objects.Line{}
+	for _, shape := range shapes {
+		switch s := shape.(type) {
+		case *objects.Line:
+			lines = append(lines, s)
+		case *objects.Polyline:
+			for _, points := range s.Stroke.Dashes(s.Points, s.Closed) {
+				dash := objects.NewPolyline(points...)
+				dash.Closed = s.Closed && !s.Stroke.IsDashed()
+				lines = append(lines, dash.Lines()...)
+			}
+		case *objects.Circle:
+			if !s.Filled {
+				lines = append(lines, outlineLines(s.Ellipse())...)
+			}
+		case *objects.Ellipse:
+			if !s.Filled {
+				lines = append(lines, outlineLines(s)...)
+			}
+		case *objects.Arc:
+			lines = append(lines, objects.NewPolyline(s.Points(DefaultFlatness)...).Lines()...)
+		case *objects.Bezier:
+			lines = append(lines, objects.NewPolyline(s.Points(DefaultFlatness)...).Lines()...)
 		}
 	}
+	return lines
+}
 
-	return lines, nil
+// outlineLines returns the segments of the closed polygon approximating the
+// outline of the given ellipse within DefaultFlatness
+func outlineLines(e *objects.Ellipse) []*objects.Line {
+	outline := objects.NewPolyline(e.Outline(DefaultFlatness)...)
+	outline.Closed = true
+	return outline.Lines()
+}
+
+// PageFilename returns the name of the output file for the given page of a
+// multi-page document, numbering the given file name before its extension
+// (i.e. output.xpm becomes output-2.xpm for the second page)
+func PageFilename(filename string, page int) string {
+	ext := filepath.Ext(filename)
+	base := filename[:len(filename)-len(ext)]
+	return fmt.Sprintf("%s-%d%s", base, page, ext)
+}
+
+// ParseShapes interprets a postscript file just like ParseFile does, but
+// returns everything the file paints in painting order: the Lines it strokes
+// as well as the Polygons filled by fill, eofill and rectfill
+func ParseShapes(filename string) ([]objects.Shape, error) {
+	shapes := []objects.Shape{}
+
+	err := WalkFile(filename, func(shape objects.Shape) error {
+		shapes = append(shapes, shape)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shapes, nil
+}
+
+// WalkFile interprets a postscript file, calling fn on every shape it paints
+// as soon as it is painted
+// Unlike ParseShapes, it never holds more than a single shape in memory
+func WalkFile(filename string, fn func(shape objects.Shape) error) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return NewDecoder(f).Walk(fn)
 }
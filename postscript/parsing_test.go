@@ -0,0 +1,129 @@
+package postscript
+
+import (
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// parseSource writes the given postscript source to a file and parses it with
+// ParseFile
+func parseSource(t *testing.T, src string) []*point {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "test.ps")
+	if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lines, err := ParseFile(filename)
+	if err != nil {
+		t.Fatalf("ParseFile: %s", err)
+	}
+
+	points := []*point{}
+	for _, l := range lines {
+		points = append(points, &point{l.A.X, l.A.Y}, &point{l.B.X, l.B.Y})
+	}
+	return points
+}
+
+func TestParseFileArc(t *testing.T) {
+	points := parseSource(t, "newpath 0 0 10 0 360 arc stroke\n")
+	if len(points) == 0 {
+		t.Fatal("stroked circle yields no lines")
+	}
+
+	// every end of the flattened circle lies on it
+	for _, p := range points {
+		if r := math.Hypot(p.x, p.y); math.Abs(r-10) > 1e-9 {
+			t.Errorf("point (%g, %g) lies %g from the center, want 10", p.x, p.y, r)
+		}
+	}
+
+	if points := parseSource(t, "newpath 0 0 10 0 360 arc fill\n"); len(points) != 0 {
+		t.Errorf("filled circle yields %d line ends, want none", len(points))
+	}
+}
+
+func TestParseFileThickStroke(t *testing.T) {
+	points := parseSource(t, "5 setlinewidth newpath 0 0 moveto 100 0 lineto 100 50 lineto stroke\n")
+
+	want := []point{{0, 0}, {100, 0}, {100, 0}, {100, 50}}
+	if len(points) != len(want) {
+		t.Fatalf("thick stroke yields %d line ends, want %d", len(points), len(want))
+	}
+	for i, p := range points {
+		if *p != want[i] {
+			t.Errorf("line end %d is (%g, %g), want (%g, %g)", i, p.x, p.y, want[i].x, want[i].y)
+		}
+	}
+}
+
+func TestParseFileDashedStroke(t *testing.T) {
+	points := parseSource(t, "[10 10] 0 setdash newpath 0 0 moveto 100 0 lineto stroke\n")
+
+	// five dashes, from 0 to 10, 20 to 30 and so on
+	if len(points) != 10 {
+		t.Fatalf("dashed stroke yields %d line ends, want 10", len(points))
+	}
+	for i := 0; i < 5; i++ {
+		a, b := points[2*i], points[2*i+1]
+		if math.Abs(a.x-float64(20*i)) > 1e-9 || math.Abs(b.x-float64(20*i+10)) > 1e-9 || a.y != 0 || b.y != 0 {
+			t.Errorf("dash %d goes from (%g, %g) to (%g, %g), want (%d, 0) to (%d, 0)",
+				i, a.x, a.y, b.x, b.y, 20*i, 20*i+10)
+		}
+	}
+}
+
+func TestParseFileZeroLengthDashes(t *testing.T) {
+	points := parseSource(t, "[0 4] 0 setdash 1 setlinecap 3 setlinewidth newpath 0 0 moveto 8 0 lineto stroke\n")
+
+	// a point every 4 units, both ends included
+	if len(points) != 6 {
+		t.Fatalf("dotted stroke yields %d line ends, want 6", len(points))
+	}
+	for i := 0; i < 3; i++ {
+		a, b := points[2*i], points[2*i+1]
+		if *a != *b || math.Abs(a.x-float64(4*i)) > 1e-9 || a.y != 0 {
+			t.Errorf("dot %d goes from (%g, %g) to (%g, %g), want (%d, 0)", i, a.x, a.y, b.x, b.y, 4*i)
+		}
+	}
+}
+
+func TestArcWholeTurns(t *testing.T) {
+	// whole turns apart, however far from 0, sweep a whole turn
+	for a1 := -720.0; a1 <= 720; a1 += 10 {
+		for a2 := -720.0; a2 <= 720; a2 += 10 {
+			if a1 == a2 || math.Mod(a2-a1, 360) != 0 {
+				continue
+			}
+			for _, clockwise := range []bool{false, true} {
+				from, to, err := arcAngles(a1, a2, clockwise)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if math.Abs(math.Abs(to-from)-2*math.Pi) > 1e-9 {
+					t.Errorf("arc from %g to %g (clockwise: %t) sweeps %g radians, want a whole turn", a1, a2, clockwise, to-from)
+				}
+			}
+		}
+	}
+
+	for _, src := range []string{
+		"newpath 0 0 10 -710 -350 arc stroke\n",
+		"newpath 0 0 10 710 350 arcn stroke\n",
+	} {
+		if points := parseSource(t, src); len(points) == 0 {
+			t.Errorf("%q yields no lines", src)
+		}
+	}
+}
+
+func TestParseFileLineProcedure(t *testing.T) {
+	points := parseSource(t, lineProcedure+"\n99 67 102 65 Line\n")
+	if len(points) != 2 || *points[0] != (point{99, 67}) || *points[1] != (point{102, 65}) {
+		t.Errorf("Line is read as %v, want from (99, 67) to (102, 65)", points)
+	}
+}
@@ -0,0 +1,183 @@
+package postscript
+
+import (
+	"fmt" // for fmt.Errorf
+
+	"../fonts/hershey"
+)
+
+// font is a postscript font object: one of the embedded Hershey fonts set in
+// a given size
+type font struct {
+	face *hershey.Font
+	size float64
+}
+
+// String satisfies fmt.Stringer.
+func (f *font) String() string {
+	return fmt.Sprintf("--%s %g--", f.face.Name, f.size)
+}
+
+// findFont returns the font with the given name in a size of 1 unit
+// As only the Hershey fonts are embedded, any other font is substituted by
+// Hershey Simplex, much like most interpreters fall back to Courier
+func findFont(name string) *font {
+	face, ok := hershey.Lookup(name)
+	if !ok {
+		face = hershey.Simplex
+	}
+
+	return &font{face: face, size: 1}
+}
+
+// popFont pops a font off the operand stack
+func (in *Interpreter) popFont() (*font, error) {
+	obj, err := in.pop()
+	if err != nil {
+		return nil, err
+	}
+
+	f, ok := obj.(*font)
+	if !ok {
+		return nil, fmt.Errorf("Type check: expected font, got %v", obj)
+	}
+	return f, nil
+}
+
+// popString pops a string off the operand stack
+func (in *Interpreter) popString() (string, error) {
+	obj, err := in.pop()
+	if err != nil {
+		return "", err
+	}
+
+	str, ok := obj.(string)
+	if !ok {
+		return "", fmt.Errorf("Type check: expected string, got %v", obj)
+	}
+	return str, nil
+}
+
+// popFontName pops a font name (either a literal name or a string) off the
+// operand stack
+func (in *Interpreter) popFontName() (string, error) {
+	obj, err := in.pop()
+	if err != nil {
+		return "", err
+	}
+
+	switch n := obj.(type) {
+	case literal:
+		return string(n), nil
+	case string:
+		return n, nil
+	}
+	return "", fmt.Errorf("Type check: expected font name, got %v", obj)
+}
+
+// opFindfont implements: key findfont font
+func opFindfont(in *Interpreter) error {
+	key, err := in.popFontName()
+	if err != nil {
+		return err
+	}
+
+	in.push(findFont(key))
+	return nil
+}
+
+// opScalefont implements: font scale scalefont font'
+func opScalefont(in *Interpreter) error {
+	nums, err := in.popNumbers(1)
+	if err != nil {
+		return err
+	}
+	f, err := in.popFont()
+	if err != nil {
+		return err
+	}
+
+	in.push(&font{face: f.face, size: f.size * nums[0]})
+	return nil
+}
+
+// opSetfont implements: font setfont -
+func opSetfont(in *Interpreter) error {
+	f, err := in.popFont()
+	if err != nil {
+		return err
+	}
+
+	in.gs.font = f
+	return nil
+}
+
+// opCurrentfont implements: - currentfont font
+func opCurrentfont(in *Interpreter) error {
+	if in.gs.font == nil {
+		return fmt.Errorf("Invalid font: no current font")
+	}
+
+	in.push(in.gs.font)
+	return nil
+}
+
+// opSelectfont implements: key scale selectfont -
+func opSelectfont(in *Interpreter) error {
+	nums, err := in.popNumbers(1)
+	if err != nil {
+		return err
+	}
+	key, err := in.popFontName()
+	if err != nil {
+		return err
+	}
+
+	f := findFont(key)
+	f.size = nums[0]
+	in.gs.font = f
+	return nil
+}
+
+// opShow implements: string show -
+// The glyphs' strokes are painted as Lines starting from the current point,
+// which is then advanced past the end of the string
+func opShow(in *Interpreter) error {
+	str, err := in.popString()
+	if err != nil {
+		return err
+	}
+
+	gs := in.gs
+	if gs.font == nil {
+		return fmt.Errorf("Invalid font: no current font")
+	}
+	if !gs.hasCur {
+		return fmt.Errorf("No current point")
+	}
+
+	cur := gs.current
+	for _, line := range gs.font.face.Layout(str, cur.x, cur.y, gs.font.size) {
+		if err := in.paint(line); err != nil {
+			return err
+		}
+	}
+
+	in.moveTo(point{cur.x + gs.font.face.StringWidth(str, gs.font.size), cur.y})
+	return nil
+}
+
+// opStringwidth implements: string stringwidth wx wy
+func opStringwidth(in *Interpreter) error {
+	str, err := in.popString()
+	if err != nil {
+		return err
+	}
+	if in.gs.font == nil {
+		return fmt.Errorf("Invalid font: no current font")
+	}
+
+	in.push(in.gs.font.face.StringWidth(str, in.gs.font.size))
+	in.push(0.0)
+	return nil
+}
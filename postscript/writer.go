@@ -0,0 +1,274 @@
+package postscript
+
+import (
+	"bytes"         // for bytes.Buffer
+	"fmt"           // for fmt.Fprintf and fmt.Errorf
+	"io"            // for io.Writer
+	"io/ioutil"     // for ioutil.WriteFile
+	"math"          // for math.Floor, math.Ceil and math.Pi
+	"path/filepath" // for filepath.Base
+	"strconv"       // for strconv.FormatFloat
+	"strings"       // for strings.HasSuffix and strings.ToLower
+
+	"./objects"
+)
+
+// lineProcedure is the definition of the Line procedure all the input files
+// share; written documents use it as well so they can be read back as usual
+const lineProcedure = "/Line {moveto lineto stroke} bind def"
+
+// Writer serializes shapes into a standalone postscript document, complete
+// with the DSC (Document Structuring Conventions) comments viewers expect
+type Writer struct {
+	w io.Writer
+
+	// EPS makes the Writer produce an Encapsulated PostScript file rather
+	// than a regular postscript document
+	EPS bool
+
+	// Title and Creator are written out in their respective DSC comments
+	// The comments are left out for empty values
+	Title   string
+	Creator string
+}
+
+// NewWriter returns a new Writer writing regular postscript to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, Creator: "go-cg"}
+}
+
+// BoundingBox returns the smallest integer box containing all the given shapes,
+// as its lower left and upper right corners
+// The box is grown by one unit on each side to make room for the width of
+// the strokes; an empty slice of shapes yields an empty box
+func BoundingBox(shapes []objects.Shape) (llx, lly, urx, ury int) {
+	b := objects.ShapesBounds(shapes)
+	if b == nil {
+		return 0, 0, 0, 0
+	}
+
+	llx, lly = int(math.Floor(b.LLX))-1, int(math.Floor(b.LLY))-1
+	urx, ury = int(math.Ceil(b.URX))+1, int(math.Ceil(b.URY))+1
+	return
+}
+
+// formatNumber formats a coordinate as a postscript number, in its shortest
+// representation and without resorting to exponents
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// ellipseFlatness is the maximum distance (in pixels) between an Ellipse and
+// the polygon its outline is written out as, postscript having no ellipses
+const ellipseFlatness = 0.25
+
+// writeSubpath writes out the given points as a subpath, closing it if so
+// requested
+func writeSubpath(buf *bytes.Buffer, points []*objects.Point, closed bool) {
+	for i, p := range points {
+		op := "lineto"
+		if i == 0 {
+			op = "moveto"
+		}
+		fmt.Fprintf(buf, "%s %s %s\n", formatNumber(p.X), formatNumber(p.Y), op)
+	}
+	if closed {
+		buf.WriteString("closepath\n")
+	}
+}
+
+// paintOp returns the painting operator for a shape which is either filled
+// or stroked
+func paintOp(filled bool) string {
+	if filled {
+		return "fill"
+	}
+	return "stroke"
+}
+
+// writeShape writes out the postscript code painting the given shape
+func writeShape(buf *bytes.Buffer, shape objects.Shape) error {
+	switch s := shape.(type) {
+	case *objects.Line:
+		fmt.Fprintf(buf, "%s %s %s %s Line\n",
+			formatNumber(s.A.X), formatNumber(s.A.Y),
+			formatNumber(s.B.X), formatNumber(s.B.Y))
+
+	case *objects.Polygon:
+		buf.WriteString("newpath\n")
+		for _, ring := range s.Rings {
+			writeSubpath(buf, ring, true)
+		}
+
+		if s.Rule == objects.EvenOdd {
+			buf.WriteString("eofill\n")
+		} else {
+			buf.WriteString("fill\n")
+		}
+
+	case *objects.Polyline:
+		if s.Stroke == nil {
+			buf.WriteString("newpath\n")
+			writeSubpath(buf, s.Points, s.Closed)
+			buf.WriteString("stroke\n")
+			break
+		}
+
+		fmt.Fprintf(buf, "gsave\n%s setlinewidth %d setlinecap %d setlinejoin %s setmiterlimit\n",
+			formatNumber(s.Stroke.Width), s.Stroke.Cap, s.Stroke.Join,
+			formatNumber(s.Stroke.MiterLimit))
+
+		// postscript always measures dashes by length
+		if s.Stroke.IsDashed() {
+			dash := make([]string, len(s.Stroke.Dash))
+			for i, d := range s.Stroke.Dash {
+				dash[i] = formatNumber(d)
+			}
+			fmt.Fprintf(buf, "[%s] %s setdash\n", strings.Join(dash, " "), formatNumber(s.Stroke.DashOffset))
+		}
+
+		buf.WriteString("newpath\n")
+		writeSubpath(buf, s.Points, s.Closed)
+		buf.WriteString("stroke\ngrestore\n")
+
+	case *objects.Circle:
+		fmt.Fprintf(buf, "newpath\n%s %s %s 0 360 arc\n%s\n",
+			formatNumber(s.Center.X), formatNumber(s.Center.Y), formatNumber(s.R),
+			paintOp(s.Filled))
+
+	case *objects.Ellipse:
+		buf.WriteString("newpath\n")
+		writeSubpath(buf, s.Outline(ellipseFlatness), true)
+		buf.WriteString(paintOp(s.Filled) + "\n")
+
+	case *objects.Arc:
+		// circular arcs are written as such, others are approximated
+		buf.WriteString("newpath\n")
+		if e := s.Ellipse; e.RX == e.RY {
+			toDegrees := 180 / math.Pi
+			fmt.Fprintf(buf, "%s %s %s %s %s arc\n",
+				formatNumber(e.Center.X), formatNumber(e.Center.Y), formatNumber(e.RX),
+				formatNumber((s.T0+e.Angle)*toDegrees), formatNumber((s.T1+e.Angle)*toDegrees))
+		} else {
+			writeSubpath(buf, s.Points(ellipseFlatness), false)
+		}
+		buf.WriteString("stroke\n")
+
+	case *objects.Bezier:
+		fmt.Fprintf(buf, "newpath\n%s %s moveto\n%s %s %s %s %s %s curveto\nstroke\n",
+			formatNumber(s.P0.X), formatNumber(s.P0.Y),
+			formatNumber(s.P1.X), formatNumber(s.P1.Y),
+			formatNumber(s.P2.X), formatNumber(s.P2.Y),
+			formatNumber(s.P3.X), formatNumber(s.P3.Y))
+
+	default:
+		return fmt.Errorf("Unsupported shape type %T", shape)
+	}
+
+	return nil
+}
+
+// WriteShapes writes out a whole single-page postscript document painting all
+// the given shapes in order
+// Lines are written with the usual "x1 y1 x2 y2 Line" convention, Polygons
+// as paths which are filled according to their fill rule, Polylines as paths
+// which are stroked (along with their line width, caps, joins and dashes, if
+// they have a stroke of their own), Circles as full arcs and Bézier curves
+// with curveto; Ellipses and the Arcs of non-circular ones are approximated
+// by polygons and polylines
+func (pw *Writer) WriteShapes(shapes []objects.Shape) error {
+	return pw.WritePages([][]objects.Shape{shapes})
+}
+
+// WritePages writes out a whole postscript document with one page for each
+// of the given slices of shapes, painting them just like WriteShapes
+// The bounding box of the document contains the shapes of all the pages
+// Returns an error if the Writer produces Encapsulated PostScript, which
+// only ever holds a single page, and there are several pages
+func (pw *Writer) WritePages(pages [][]objects.Shape) error {
+	if pw.EPS && len(pages) != 1 {
+		return fmt.Errorf("Encapsulated PostScript holds a single page, got %d", len(pages))
+	}
+
+	buf := &bytes.Buffer{}
+
+	// the header comments
+	if pw.EPS {
+		buf.WriteString("%!PS-Adobe-3.0 EPSF-3.0\n")
+	} else {
+		buf.WriteString("%!PS-Adobe-3.0\n")
+	}
+
+	all := []objects.Shape{}
+	for _, shapes := range pages {
+		all = append(all, shapes...)
+	}
+	llx, lly, urx, ury := BoundingBox(all)
+	fmt.Fprintf(buf, "%%%%BoundingBox: %d %d %d %d\n", llx, lly, urx, ury)
+
+	if pw.Title != "" {
+		fmt.Fprintf(buf, "%%%%Title: %s\n", pw.Title)
+	}
+	if pw.Creator != "" {
+		fmt.Fprintf(buf, "%%%%Creator: %s\n", pw.Creator)
+	}
+	fmt.Fprintf(buf, "%%%%Pages: %d\n", len(pages))
+	buf.WriteString("%%EndComments\n")
+
+	// the prolog defining our procedures
+	buf.WriteString("%%BeginProlog\n")
+	buf.WriteString(lineProcedure + "\n")
+	buf.WriteString("%%EndProlog\n")
+
+	// the pages, with the usual BEGIN and END tags around their shapes
+	for i, shapes := range pages {
+		fmt.Fprintf(buf, "%%%%Page: %d %d\n", i+1, i+1)
+		buf.WriteString("%%%BEGIN\n")
+		for _, shape := range shapes {
+			if err := writeShape(buf, shape); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("%%%END\n")
+		buf.WriteString("showpage\n")
+	}
+
+	buf.WriteString("%%Trailer\n")
+	buf.WriteString("%%EOF\n")
+
+	_, err := pw.w.Write(buf.Bytes())
+	return err
+}
+
+// LinesToShapes converts the given slice of Lines to a slice of Shapes
+func LinesToShapes(lines []*objects.Line) []objects.Shape {
+	shapes := make([]objects.Shape, len(lines))
+	for i, line := range lines {
+		shapes[i] = line
+	}
+	return shapes
+}
+
+// WriteLines writes out a whole postscript document stroking the given Lines
+func (pw *Writer) WriteLines(lines []*objects.Line) error {
+	return pw.WriteShapes(LinesToShapes(lines))
+}
+
+// WriteFile writes out a postscript document painting the given shapes to the
+// file with the given name, which is its title as well (without the directory)
+// If the file's name ends in .eps, it is written out as Encapsulated PostScript
+// If the file does not exist, it will be created with default 0644 permissions
+// If the file exists, it will be truncated
+func WriteFile(filename string, shapes []objects.Shape) error {
+	buf := &bytes.Buffer{}
+
+	pw := NewWriter(buf)
+	pw.EPS = strings.HasSuffix(strings.ToLower(filename), ".eps")
+	pw.Title = filepath.Base(filename)
+
+	if err := pw.WriteShapes(shapes); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filename, buf.Bytes(), 0644)
+}
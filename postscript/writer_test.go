@@ -0,0 +1,84 @@
+package postscript
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"./objects"
+)
+
+func TestWriteFileRoundTrip(t *testing.T) {
+	lines := []*objects.Line{
+		objects.NewLine(objects.NewPoint(10, 20), objects.NewPoint(30, 40)),
+		objects.NewLine(objects.NewPoint(50, 5), objects.NewPoint(0, 0)),
+	}
+
+	filename := filepath.Join(t.TempDir(), "lines.ps")
+	if err := WriteFile(filename, LinesToShapes(lines)); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ParseFile(filename)
+	if err != nil {
+		t.Fatalf("ParseFile: %s", err)
+	}
+	if len(read) != len(lines) {
+		t.Fatalf("read back %d lines, want %d", len(read), len(lines))
+	}
+	for i, l := range read {
+		if *l.A != *lines[i].A || *l.B != *lines[i].B {
+			t.Errorf("%d'th line is read back as %s, want %s", i, l, lines[i])
+		}
+	}
+
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(contents), "%%Title: lines.ps\n") {
+		t.Errorf("title is not the base name of the file:\n%s", contents)
+	}
+}
+
+func TestWritePages(t *testing.T) {
+	line := func(x float64) objects.Shape {
+		return objects.NewLine(objects.NewPoint(x, 0), objects.NewPoint(x, 10))
+	}
+	pages := [][]objects.Shape{{line(1)}, {line(2), line(3)}, {}}
+
+	buf := &bytes.Buffer{}
+	if err := NewWriter(buf).WritePages(pages); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := buf.String()
+	for _, want := range []string{"%%Pages: 3\n", "%%Page: 1 1\n", "%%Page: 2 2\n", "%%Page: 3 3\n"} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("document lacks %q:\n%s", want, doc)
+		}
+	}
+	if n := strings.Count(doc, "showpage\n"); n != 3 {
+		t.Errorf("document shows %d pages, want 3", n)
+	}
+
+	filename := filepath.Join(t.TempDir(), "pages.ps")
+	if err := ioutil.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ParsePages(filename)
+	if err != nil {
+		t.Fatalf("ParsePages: %s", err)
+	}
+	if len(read) != 3 || len(read[0]) != 1 || len(read[1]) != 2 || len(read[2]) != 0 {
+		t.Errorf("read back pages of %v shapes", read)
+	}
+
+	eps := NewWriter(&bytes.Buffer{})
+	eps.EPS = true
+	if err := eps.WritePages(pages); err == nil {
+		t.Error("multi-page EPS written")
+	}
+}
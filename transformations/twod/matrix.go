@@ -2,6 +2,8 @@ package twod
 
 import (
 	"fmt"
+
+	"../../postscript/objects"
 )
 
 // Matrix is the basic 2d transformation matrix object.
@@ -24,7 +26,7 @@ func (m *Matrix) String() string {
 	for _, row := range m.rows {
 		res = res + "| "
 		for _, n := range row {
-			res = res + fmt.Sprintf("%d ", int(n))
+			res = res + fmt.Sprintf("%g ", n)
 		}
 		res = res[:len(res)] + "|\n"
 	}
@@ -55,3 +57,9 @@ func (m *Matrix) Multiply(other *Matrix) *Matrix {
 
 	return res
 }
+
+// TransformPoint applies the transformation represented by this Matrix to the
+// given Point, satisfying the objects.Transformer interface.
+func (m *Matrix) TransformPoint(p *objects.Point) *objects.Point {
+	return getPointFromMatrix(m.Multiply(makePointMatrix(p)))
+}
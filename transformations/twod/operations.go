@@ -7,12 +7,12 @@ import (
 )
 
 // TranslatePoint applies a 2D translation with the specified parameters to the given Point.
-func TranslatePoint(p *objects.Point, tx, ty int) *objects.Point {
+func TranslatePoint(p *objects.Point, tx, ty float64) *objects.Point {
 	return getPointFromMatrix(new2DTranslationMatrix(tx, ty).Multiply(makePointMatrix(p)))
 }
 
 // TranslateLine applies a 2D translation with the specified parameters to the given Line.
-func TranslateLine(l *objects.Line, tx, ty int) *objects.Line {
+func TranslateLine(l *objects.Line, tx, ty float64) *objects.Line {
 	return objects.NewLine(
 		TranslatePoint(l.A, tx, ty),
 		TranslatePoint(l.B, tx, ty),
@@ -21,23 +21,23 @@ func TranslateLine(l *objects.Line, tx, ty int) *objects.Line {
 
 // new2DTranslationMatrix returns a 3x3 Matrix which represents the operation
 // of 2D translation by the given parameters.
-func new2DTranslationMatrix(tx, ty int) *Matrix {
+func new2DTranslationMatrix(tx, ty float64) *Matrix {
 	return &Matrix{
 		[][]float64{
-			[]float64{1, 0, float64(tx)},
-			[]float64{0, 1, float64(ty)},
+			[]float64{1, 0, tx},
+			[]float64{0, 1, ty},
 			[]float64{0, 0, 1},
 		},
 	}
 }
 
 // RotatePointAroundPoint applies a 2D rotation with the given angle to a given Point.
-func RotatePointAroundPoint(p, o *objects.Point, angle int) *objects.Point {
+func RotatePointAroundPoint(p, o *objects.Point, angle float64) *objects.Point {
 	return getPointFromMatrix(new2DRotationMatrix(o, angle).Multiply(makePointMatrix(p)))
 }
 
 // RotateLineAroundPoint applies a 2D rotation with the given angle to a given Line.
-func RotateLineAroundPoint(l *objects.Line, p *objects.Point, angle int) *objects.Line {
+func RotateLineAroundPoint(l *objects.Line, p *objects.Point, angle float64) *objects.Line {
 	return objects.NewLine(
 		RotatePointAroundPoint(l.A, p, angle),
 		RotatePointAroundPoint(l.B, p, angle),
@@ -46,7 +46,7 @@ func RotateLineAroundPoint(l *objects.Line, p *objects.Point, angle int) *object
 
 // new2DRotationMatrix returns a 3x3 Matrix which represents the operation
 // of 2D rotation with the given angle around a given point.
-func new2DRotationMatrix(p *objects.Point, angle int) *Matrix {
+func new2DRotationMatrix(p *objects.Point, angle float64) *Matrix {
 	// NOTE(aznashwan): this may be other order around...
 	a := degToRadians(angle)
 	rotationMatrix := &Matrix{
@@ -0,0 +1,30 @@
+package twod
+
+import (
+	"math"
+	"testing"
+
+	"../../postscript/objects"
+)
+
+func TestRotatePointAroundPoint(t *testing.T) {
+	tests := []struct {
+		p, o  *objects.Point
+		angle float64
+		x, y  float64
+	}{
+		{objects.NewPoint(10, 0), objects.NewPoint(0, 0), 90, 0, 10},
+		{objects.NewPoint(10, 0), objects.NewPoint(0, 0), -90, 0, -10},
+		{objects.NewPoint(10, 0), objects.NewPoint(5, 5), 180, 0, 10},
+		{objects.NewPoint(10, 0), objects.NewPoint(0, 0), 360, 10, 0},
+		{objects.NewPoint(10, 0), objects.NewPoint(0, 0), 45, 10 / math.Sqrt2, 10 / math.Sqrt2},
+	}
+
+	for _, test := range tests {
+		r := RotatePointAroundPoint(test.p, test.o, test.angle)
+		if math.Abs(r.X-test.x) > 1e-9 || math.Abs(r.Y-test.y) > 1e-9 {
+			t.Errorf("%s rotated by %g degrees around %s is %s, want (%g, %g)",
+				test.p, test.angle, test.o, r, test.x, test.y)
+		}
+	}
+}
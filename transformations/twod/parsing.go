@@ -50,7 +50,7 @@ func ParseFile(file string) ([][]interface{}, error) {
 				return nil, err
 			}
 			operations = append(operations, []interface{}{
-				"t", int(floats[0]), int(floats[1]),
+				"t", floats[0], floats[1],
 			})
 		case "s":
 			floats, err := atofs(items[i+1 : i+5])
@@ -58,7 +58,7 @@ func ParseFile(file string) ([][]interface{}, error) {
 				return nil, err
 			}
 			operations = append(operations, []interface{}{
-				"s", int(floats[0]), int(floats[1]), floats[2], floats[3],
+				"s", floats[0], floats[1], floats[2], floats[3],
 			})
 		case "r":
 			floats, err := atofs(items[i+1 : i+4])
@@ -66,7 +66,7 @@ func ParseFile(file string) ([][]interface{}, error) {
 				return nil, err
 			}
 			operations = append(operations, []interface{}{
-				"r", int(floats[0]), int(floats[1]), int(floats[2]),
+				"r", floats[0], floats[1], floats[2],
 			})
 		}
 	}
@@ -74,23 +74,67 @@ func ParseFile(file string) ([][]interface{}, error) {
 	return operations, nil
 }
 
+// operationMatrix returns the Matrix representing the given operation.
+func operationMatrix(op []interface{}) *Matrix {
+	switch op[0].(string) {
+	case "t":
+		return new2DTranslationMatrix(op[1].(float64), op[2].(float64))
+	case "s":
+		return new2DScalingMatrix(
+			objects.NewPoint(op[1].(float64), op[2].(float64)),
+			op[3].(float64),
+			op[4].(float64),
+		)
+	case "r":
+		return new2DRotationMatrix(
+			objects.NewPoint(op[1].(float64), op[2].(float64)),
+			op[3].(float64),
+		)
+	}
+
+	return nil
+}
+
+// ComposeTransformations returns the single Matrix which applies all the given
+// operations, in order.
+func ComposeTransformations(ops [][]interface{}) *Matrix {
+	res := new2DTranslationMatrix(0, 0)
+	for _, op := range ops {
+		res = operationMatrix(op).Multiply(res)
+	}
+	return res
+}
+
+// ApplyTransformations applies all the given transformations to the given
+// shapes and returns the result:
+func ApplyTransformations(shapes []objects.Shape, ops [][]interface{}) []objects.Shape {
+	m := ComposeTransformations(ops)
+
+	res := []objects.Shape{}
+	for _, shape := range shapes {
+		res = append(res, shape.Transform(m))
+	}
+
+	return res
+}
+
 // applyOperationToLine applies the given operation to the the given Line.
 func applyOperationToLine(l *objects.Line, op []interface{}) *objects.Line {
 	switch op[0].(string) {
 	case "t":
-		return TranslateLine(l, op[1].(int), op[2].(int))
+		return TranslateLine(l, op[1].(float64), op[2].(float64))
 	case "s":
 		return ScaleLineAroundPoint(
 			l,
-			objects.NewPoint(op[1].(int), op[2].(int)),
+			objects.NewPoint(op[1].(float64), op[2].(float64)),
 			op[3].(float64),
 			op[4].(float64),
 		)
 	case "r":
 		return RotateLineAroundPoint(
 			l,
-			objects.NewPoint(op[1].(int), op[2].(int)),
-			op[3].(int),
+			objects.NewPoint(op[1].(float64), op[2].(float64)),
+			op[3].(float64),
 		)
 	}
 
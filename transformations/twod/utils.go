@@ -7,16 +7,16 @@ import (
 )
 
 // degToRadians converts the given number of degrees to radians.
-func degToRadians(degs int) float64 {
-	return float64(degs) * 180 / math.Pi
+func degToRadians(degs float64) float64 {
+	return degs * math.Pi / 180
 }
 
 // makePointMatrix takes a point and makes its respective column matrix.
 func makePointMatrix(p *objects.Point) *Matrix {
 	return &Matrix{
 		[][]float64{
-			[]float64{float64(p.X)},
-			[]float64{float64(p.Y)},
+			[]float64{p.X},
+			[]float64{p.Y},
 			[]float64{1},
 		},
 	}
@@ -24,5 +24,5 @@ func makePointMatrix(p *objects.Point) *Matrix {
 
 // getPointFromMatrix extracts the value of a Point from its characteristic matrix.
 func getPointFromMatrix(m *Matrix) *objects.Point {
-	return objects.NewPoint(int(m.rows[0][0]), int(m.rows[1][0]))
+	return objects.NewPoint(m.rows[0][0], m.rows[1][0])
 }
@@ -0,0 +1,118 @@
+package xpm
+
+import (
+	"fmt"  // for fmt.Errorf
+	"math" // for math.Round, math.Max and math.Min
+)
+
+// blendLevels is the number of distinct coverages blended pixels are
+// quantized to, to keep the number of intermediate colors in the palette down
+const blendLevels = 16
+
+// keyChars are the characters intermediate colors are encoded with; they are
+// all the printable ASCII characters other than the space which need no
+// escaping inside a C string
+const keyChars = "!#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[]^_`abcdefghijklmnopqrstuvwxyz{|}~"
+
+// color returns the color with the given character combination, or nil if
+// there is none
+func (xpm *XPM) color(cc string) *Color {
+	for i := range xpm.colors {
+		if xpm.colors[i].chars == cc {
+			return &xpm.colors[i]
+		}
+	}
+	return nil
+}
+
+// Blend returns the mix of the two 8-bit color components at the given
+// coverage of the second one
+func Blend(bg, fg byte, alpha float64) byte {
+	return byte(float64(bg)*(1-alpha) + float64(fg)*alpha + 0.5)
+}
+
+// nextKey returns the n-th character combination of cpp characters out of
+// keyChars, or false if there are not that many
+func (xpm *XPM) nextKey(n int) (string, bool) {
+	key := make([]byte, xpm.cpp)
+	for i := xpm.cpp - 1; i >= 0; i-- {
+		key[i] = keyChars[n%len(keyChars)]
+		n /= len(keyChars)
+	}
+	return string(key), n == 0
+}
+
+// colorFor returns the character combination of the color with the given
+// components, allocating a new one in the palette if there is no such color
+// yet
+// When the palette runs out of character combinations, the closest color
+// already in it is returned instead
+func (xpm *XPM) colorFor(r, g, b byte) string {
+	for _, color := range xpm.colors {
+		if color.red == r && color.green == g && color.blue == b {
+			return color.chars
+		}
+	}
+
+	for n := 0; ; n++ {
+		key, ok := xpm.nextKey(n)
+		if !ok {
+			break
+		}
+		if xpm.color(key) == nil {
+			xpm.colors = append(xpm.colors, Color{key, r, g, b})
+			return key
+		}
+	}
+
+	// squared distance between the components of two colors
+	dist := func(c Color) int {
+		dr, dg, db := int(c.red)-int(r), int(c.green)-int(g), int(c.blue)-int(b)
+		return dr*dr + dg*dg + db*db
+	}
+
+	best := xpm.colors[0]
+	for _, color := range xpm.colors[1:] {
+		if dist(color) < dist(best) {
+			best = color
+		}
+	}
+	return best.chars
+}
+
+// BlendPixelCartesian paints a pixel at the given 0-ordered right-handed
+// cartesian coordinates x and y with the given color character combination at
+// the given coverage (from 0 to 1), blending it with the pixel's current color
+// The coverage is quantized to a few levels, and the palette is extended with
+// each intermediate color the first time it is needed
+// Returns an error if any of the given coordinates is out of range or if
+// the color character combination has not been defined
+func (xpm *XPM) BlendPixelCartesian(x, y int, cc string, alpha float64) error {
+	// the same row as SetPixelCartesian's
+	row := xpm.height - 1 - y
+	if err := xpm.validatePixel(x, row, cc); err != nil {
+		return err
+	}
+
+	alpha = math.Round(math.Max(0, math.Min(1, alpha))*blendLevels) / blendLevels
+	switch alpha {
+	case 0:
+		return nil
+	case 1:
+		xpm.data[row][x] = cc
+		return nil
+	}
+
+	bg := xpm.color(xpm.data[row][x])
+	fg := xpm.color(cc)
+	if bg == nil {
+		return fmt.Errorf("Nonexistent color combination %s in this XPM", xpm.data[row][x])
+	}
+
+	xpm.data[row][x] = xpm.colorFor(
+		Blend(bg.red, fg.red, alpha),
+		Blend(bg.green, fg.green, alpha),
+		Blend(bg.blue, fg.blue, alpha),
+	)
+	return nil
+}
@@ -53,10 +53,10 @@ func NewXPM(width, height, cpp int) *XPM {
 
 // validatePixel validates the inputs given to SetPixel
 func (xpm *XPM) validatePixel(x, y int, cc string) error {
-	if x > xpm.width {
+	if x < 0 || x >= xpm.width {
 		return fmt.Errorf("Invalid x=%d", x)
 	}
-	if y > xpm.height {
+	if y < 0 || y >= xpm.height {
 		return fmt.Errorf("Invalid y=%d", y)
 	}
 
@@ -84,10 +84,22 @@ func (xpm *XPM) SetPixel(x, y int, cc string) error {
 
 // SetPixelCartesian sets a pixel at the given 0-ordered right-handed cartesian
 // coordinates x and y and with the given color character combination
+// The cartesian row y is the row height-1-y of the data matrix, so that y goes
+// from 0 at the bottom of the image to height-1 at its top
 // Returns an error if any of the given coordinates is out of range or if
 // the color character combination has not been defined
 func (xpm *XPM) SetPixelCartesian(x, y int, cc string) error {
-	return xpm.SetPixel(x, xpm.height-y, cc)
+	return xpm.SetPixel(x, xpm.height-1-y, cc)
+}
+
+// Width returns the width of the XPM in pixels
+func (xpm *XPM) Width() int {
+	return xpm.width
+}
+
+// Height returns the height of the XPM in pixels
+func (xpm *XPM) Height() int {
+	return xpm.height
 }
 
 // AddColor adds a color to the associated XPM structure with the given